@@ -0,0 +1,36 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// goik-headless runs the simulation engine without the simulator window, reading shell commands from stdin.
+// It does not import the simulator (ebiten), so it builds and runs on machines without an X server
+package main
+
+import (
+	"GOIK/engine"
+	"GOIK/robot"
+	"flag"
+	"log"
+	"os"
+)
+
+func main() {
+	realTime := flag.Bool("realtime", false, "move the pod in real time (for streaming to a robot)")
+	flag.Parse()
+
+	e := engine.NewEngine(robot.NewPod(robot.NewExampleHexapod2()), make(chan string, 10))
+	err := e.RunHeadless(os.Stdin, os.Stdout, *realTime)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -16,6 +16,7 @@ package comms
 
 import (
 	"GOIK/robot"
+	"fmt"
 	"net"
 )
 
@@ -34,18 +35,25 @@ type NetworkController struct {
 	DebugChannel chan string
 	isRunning    bool
 	mode         ControlMode
+	// Legs streamed with clamped angles. A leg is reported once when its angles are first clamped
+	clamped []bool
 }
 
 func NewNetworkController(id uint8, p *robot.Pod, DebugChannel chan string) *NetworkController {
 	return &NetworkController{id: id,
 		pod:          p,
-		packet:       make([]byte, 2*(robot.NUM_JOINTS-1)*p.BodyDefinition.NumLegs+3),
+		packet:       make([]byte, 2*p.NumServos()+3),
 		DebugChannel: DebugChannel,
 		isRunning:    false,
 		mode:         Streaming,
 	}
 }
 
+// SetPod streams the servo angles of another pod (after a reset etc)
+func (n *NetworkController) SetPod(p *robot.Pod) {
+	n.pod = p
+}
+
 func (n *NetworkController) Update() {
 	if n.connection == nil || !n.isRunning || n.mode != Streaming {
 		return
@@ -57,9 +65,11 @@ func (n *NetworkController) Update() {
 	// 1-2: Coxa 0
 	// 3-4: Femur 0
 	// 5-6: Tibia 0
-	// 7-8: Coxa 1
+	// 7-8: Coxa 1 (or the next joint in the kinematic chain of leg 0 for legs with more than 3 joints)
 	// ...
-	// 50-51: XOR of id and all positions bytes
+	// Last 2 bytes: XOR of id and all positions bytes
+	//
+	// Every leg contributes one position per joint in its kinematic chain
 
 	// Note regarding servo orientation:
 	// Formula for mapping joint angle to raw units for dynamixel servos with non 360 degree rotation:
@@ -71,9 +81,15 @@ func (n *NetworkController) Update() {
 	// use the following formula instead
 	//	coxa = 1024 - uint16((n.pod.Legs[l].ServoAngles.Coxa/300)*1024+512)
 
-	var coxa uint16
-	var femur uint16
-	var tibia uint16
+	// The pod structure may have changed since the last frame (reset etc)
+	if len(n.packet) != 2*n.pod.NumServos()+3 {
+		n.packet = make([]byte, 2*n.pod.NumServos()+3)
+	}
+	if len(n.clamped) != len(n.pod.Legs) {
+		n.clamped = make([]bool, len(n.pod.Legs))
+	}
+
+	var joint uint16
 	var checksum uint16
 	var i = 0
 
@@ -82,27 +98,23 @@ func (n *NetworkController) Update() {
 	checksum ^= uint16(n.id)
 	for l, _ := range n.pod.Legs {
 
-		coxa = 1024 - uint16((n.pod.Legs[l].ServoAngles.Coxa/300)*1024+512)
-
-		checksum ^= coxa
-		i += 1
-		n.packet[i] = uint8(coxa & 0xFF)
-		i += 1
-		n.packet[i] = uint8((coxa & 0xFF00) >> 8)
-
-		femur = uint16((n.pod.Legs[l].ServoAngles.Femur/300)*1024 + 512)
-		checksum ^= femur
-		i += 1
-		n.packet[i] = uint8(femur & 0xFF)
-		i += 1
-		n.packet[i] = uint8((femur & 0xFF00) >> 8)
-
-		tibia = uint16((n.pod.Legs[l].ServoAngles.Tibia/300)*1024 + 512)
-		i += 1
-		n.packet[i] = uint8(tibia & 0xFF)
-		i += 1
-		n.packet[i] = uint8((tibia & 0xFF00) >> 8)
-		checksum ^= tibia
+		// Never stream angles that the servos are unable to reach
+		angles := n.pod.Legs[l].JointLimits.Clamp(n.pod.Legs[l].ServoAngles)
+		n.reportClamp(l)
+
+		for j := 0; j < n.pod.Legs[l].NumServos(); j++ {
+			if j == 0 {
+				// The coxa servo is mounted mirrored
+				joint = 1024 - uint16((angles.Get(j)/300)*1024+512)
+			} else {
+				joint = uint16((angles.Get(j)/300)*1024 + 512)
+			}
+			checksum ^= joint
+			i += 1
+			n.packet[i] = uint8(joint & 0xFF)
+			i += 1
+			n.packet[i] = uint8((joint & 0xFF00) >> 8)
+		}
 	}
 
 	i += 1
@@ -114,6 +126,17 @@ func (n *NetworkController) Update() {
 	n.connection.Write(n.packet[:])
 }
 
+// reportClamp reports the joint of a leg that is outside its limits (see robot.JointLimitError) when the
+// angles streamed for the leg are first clamped, so that a clamped angle is never streamed without notice
+func (n *NetworkController) reportClamp(legNum int) {
+	leg := n.pod.Legs[legNum]
+	err := leg.JointLimits.Check(leg.Index, leg.ServoAngles)
+	if err != nil && !n.clamped[legNum] {
+		n.DebugChannel <- fmt.Sprintf("Streaming clamped angles: %s", err.Error())
+	}
+	n.clamped[legNum] = err != nil
+}
+
 func (n *NetworkController) Dial(address string) error {
 	// Resolve the string address to a UDP address
 	udpAddr, err := net.ResolveUDPAddr("udp", address)
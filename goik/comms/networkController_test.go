@@ -0,0 +1,81 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package comms
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"GOIK/robot"
+)
+
+// Angles outside the joint limits are streamed clamped, and reported once per leg
+func TestStreamClampedAngles(t *testing.T) {
+	listener, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	output := make(chan string, 10)
+	p := robot.NewPod(robot.NewExampleHexapod1())
+	n := NewNetworkController(1, p, output)
+	if err := n.Dial(listener.LocalAddr().String()); err != nil {
+		t.Fatal(err)
+	}
+	defer n.Disconnect()
+	n.Start()
+
+	// Leg 2 has an XL-320 tibia, limited to 150 degrees
+	leg := p.Legs[2]
+	leg.ServoAngles.Tibia = 170
+	buf := make([]byte, 1024)
+	for i := 0; i < 3; i++ {
+		n.Update()
+		listener.SetReadDeadline(time.Now().Add(time.Second))
+		size, err := listener.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size != 2*p.NumServos()+3 {
+			t.Fatalf("packet of %d bytes, want %d", size, 2*p.NumServos()+3)
+		}
+		// id, then coxa, femur and tibia of each leg
+		offset := 1 + 2*(3*leg.Index+2)
+		tibia := int(buf[offset]) | int(buf[offset+1])<<8
+		if want := int(150.0/300*1024 + 512); tibia != want {
+			t.Errorf("streamed tibia %d, want %d", tibia, want)
+		}
+	}
+
+	if len(output) != 1 {
+		t.Fatalf("%d messages, want 1", len(output))
+	}
+	message := <-output
+	if !strings.Contains(message, "leg 2: tibia angle 170.00") {
+		t.Errorf("message %q does not report the leg and joint", message)
+	}
+
+	// The leg is reported again if it leaves its limits after being within them
+	leg.ServoAngles.Tibia = 100
+	n.Update()
+	leg.ServoAngles.Tibia = -170
+	n.Update()
+	if len(output) != 1 {
+		t.Errorf("%d messages, want 1", len(output))
+	}
+}
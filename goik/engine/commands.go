@@ -0,0 +1,1680 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"GOIK/robot"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const POD_FOLDER = "pods"
+const PRIMITIVES_FOLDER = "primitives"
+const TORQUE_FOLDER = "torque"
+const GAITS_FOLDER = "gaits"
+
+func (s *Shell) executeHelpCmd(args []string) error {
+	s.outputCh <- "Commands:"
+	s.outputCh <- "\teffectors                                  - output current end effector positions."
+	s.outputCh <- "\tgait <tripod | ripple | wave | metachronal> - select new gait (generated for any number of legs)."
+	s.outputCh <- "\tgait load <file>                           - load a user defined gait from the gaits folder"
+	s.outputCh <- "\tgait list                                  - list the gait files in the gaits folder"
+	s.outputCh <- "\tduty <duty factor>                         - Set the fraction (0-1) of the gait cycle each leg spends in stance"
+	s.outputCh <- "\tphase [<legNum> <offset>]                  - Output phase offsets, or set where (0-1) in the gait cycle a leg lifts off"
+	s.outputCh <- "\tswing [<ALL | legNum> <profile> [<height> [<lift-off> <touchdown>]] [<progress>,<height> ...]]"
+	s.outputCh <- "\t                                             Swing profile: sine, cycloid, quintic, rectangular or bezier (with control points)."
+	s.outputCh <- "\t                                             Vertical lift-off / touchdown speeds in mm/s"
+	s.outputCh <- "\tset_coxa_length <ALL | legNum> <length>"
+	s.outputCh <- "\tset_femur_length <ALL | legNum> <length>"
+	s.outputCh <- "\tset_tibia_length <ALL | legNum> <length>"
+	s.outputCh <- "\tset_coxa_angle <ALL | legNum> <angle>"
+	s.outputCh <- "\tset_femur_angle <ALL | legNum> <angle>"
+	s.outputCh <- "\tset_tibia_angle <ALL | legNum> <angle>"
+	s.outputCh <- "\tknee <ALL | legNum> <up|down>              - select knee configuration (IK solution) for a leg"
+	s.outputCh <- "\tlimits                                     - output joint limits for all legs"
+	s.outputCh <- "\tset_limit <ALL | legNum> <joint> <min> <max> [max deg/s]"
+	s.outputCh <- "\t                                             joint is coxa, femur, tibia, tarsus or the joint index"
+	s.outputCh <- "\t                                             min == max == 0 removes the limit"
+	s.outputCh <- "\tworkspace <legNum | off>                   - Sample and show the reachable workspace of a leg"
+	s.outputCh <- "\tground <height>                            - Grounds all end effectors and updates rest angles"
+	s.outputCh <- "\tstride_vector <nrepeats> <x> <y>           - Set direction. x & y are relative to current location"
+	s.outputCh <- "\tstride_angle <nrepeats> <degrees> [<px> <py>] - Rotate around center of gravity or the pivot (px, py) in the body frame"
+	s.outputCh <- "\tstride_motion <nrepeats> <x> <y> <degrees> [<px> <py>]"
+	s.outputCh <- "\t                                             Move x & y while turning around the pivot (px, py) in each stride (arcs)"
+	s.outputCh <- "\tvelocity <nrepeats> <vx> <vy> [<yaw>]      - Walk at a velocity (mm/s) and yaw rate (deg/s). Change it any time while walking"
+	s.outputCh <- "\tauto_gait <on|off> [ripple tripod [hyst]]  - Select wave/ripple/tripod from the velocity (switching speeds in mm/s)"
+	s.outputCh <- "\tmax_stride <x> <y>                         - Find the longest stride in direction x, y for the current gait"
+	s.outputCh <- "\tmax_stride angle [+|-]                     - Find the largest stride angle for the current gait"
+	s.outputCh <- "\tmass                                       - output total mass and centre of mass"
+	s.outputCh <- "\tpayload <grams> <x> <y> <z>                - Set payload mass and location (body reference frame)"
+	s.outputCh <- "\ttorque [csv file]                          - Estimate static joint torques over a gait cycle (optional CSV export)"
+	s.outputCh <- "\tstability                                  - output support polygon stability margin (current and worst in gait cycle)"
+	s.outputCh <- "\tpitch <degrees>                            - Pitch move (rotate body around Y)"
+	s.outputCh <- "\tyaw <degrees>                              - Yaw move (rotate body around Z)"
+	s.outputCh <- "\troll <degrees>                             - Roll move (rotate body around X)"
+	s.outputCh <- "\tup <z>                                     - Stand tall (raise body z mm)"
+	s.outputCh <- "\tdown <z>                                   - Low rider (lower body z mm)"
+	s.outputCh <- "\tshift <x> <y>                              - Shift body sideways (x & y mm)"
+	s.outputCh <- "\tpose [reset]                               - Show body pose or level and center the body"
+	s.outputCh <- "\t                                             The body pose stays in effect while walking"
+	s.outputCh <- "\tstart                                      - Start pod"
+	s.outputCh <- "\tstop                                       - Stop pod"
+	s.outputCh <- "\treset <0|1|2|3|4|5|6|7>                    - Reset to design preset <n> (6 has 4 joints per leg, 7 has 2)"
+	s.outputCh <- "\tspeed [<factor>]                           - Output or set the simulation speed relative to real time (0.1-10)"
+	s.outputCh <- "\trate [<Hz>]                                - Output or set the tick rate for interpolation, streaming and recording"
+	s.outputCh <- "\tzlift                                      - defines leg lift during swing phase"
+	s.outputCh <- "\topen <IP:port>                             - open connection to dynamixel  UDP bridge"
+	s.outputCh <- "\tclose                                      - close dynamixel connection"
+	s.outputCh <- "\tsave <filename>                            - save pod definition to file"
+	s.outputCh <- "\tload <filename>                            - load pod definition from file"
+	s.outputCh <- "\tzero                                       - Aligns all servos to zero degrees"
+	s.outputCh <- "\treverse                                    - Reverses walking direction"
+	s.outputCh <- "\tstep                                       - Performs a single cycle through the gait"
+	s.outputCh <- "\tstate                                      - Output the motion state (idle, stride defined, walking, stopping, reverting or posing)"
+	s.outputCh <- "\tevents <on|off>                            - Output state changes, completed gait cycles, neutral stance and pose reached"
+	s.outputCh <- "\trun <script>                               - Run a script of commands from the scripts folder. Motion commands wait for the pod"
+	s.outputCh <- "\t                                             Also: 'wait <ms>', 'repeat <n> { ... }' and '#' comments"
+	s.outputCh <- "\trevert                                     - Revert to a neutral position"
+	s.outputCh <- "\trecord <on [steps]|off>                    - Records next run (optionally with a number of interpolation steps) or stops recording"
+	s.outputCh <- "\tinterpolation [<steps>]                    - Output or set the number of interpolation steps (odd number) for new strides and poses"
+	s.outputCh <- "\texport <file> <max deg> <mask>             - Save recording to a file. Servo range: 180-360."
+	s.outputCh <- "\t                                             mask is of the format \"100\", where a \"1\""
+	s.outputCh <- "\t                                             signifies that the servo horn is pointing in negative Z"
+	s.outputCh <- "\t                                             and a \"0\" that it is pointing in positive Z direction"
+	s.outputCh <- "\t                                             The bitmask order is coxa, femur, tibia (, tarsus ...)"
+
+	return nil
+}
+
+func (s *Shell) executeWorkspaceCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('workspace <legNum | off>'): %+v", args)
+	}
+
+	if args[1] == "off" {
+		return s.Pod.ShowWorkspace(-1)
+	}
+
+	legnum, err := strconv.ParseInt(args[1], 10, 32)
+	if err != nil || legnum < 0 {
+		return fmt.Errorf("syntax error ('workspace <legNum | off>'): %+v", args)
+	}
+
+	err = s.Pod.ShowWorkspace(int(legnum))
+	if err != nil {
+		return err
+	}
+
+	w := s.Pod.Workspace
+	s.outputCh <- fmt.Sprintf("Workspace of leg %d (%d joint configurations sampled):", w.Leg, w.Samples)
+	for j, r := range w.JointRanges {
+		s.outputCh <- fmt.Sprintf("\t%s swept from %2.2f to %2.2f degrees", robot.JointName(j), r[0], r[1])
+	}
+	s.outputCh <- fmt.Sprintf("\tX: %2.2f to %2.2f", w.Min.X, w.Max.X)
+	s.outputCh <- fmt.Sprintf("\tY: %2.2f to %2.2f", w.Min.Y, w.Max.Y)
+	s.outputCh <- fmt.Sprintf("\tZ: %2.2f to %2.2f", w.Min.Z, w.Max.Z)
+	if len(w.GroundSlice) == 0 {
+		s.outputCh <- fmt.Sprintf("\tThe leg is unable to reach the ground (Z = %2.2f)", w.GroundHeight)
+	} else {
+		s.outputCh <- fmt.Sprintf("\tReach at ground height (Z = %2.2f): %2.2f to %2.2f from the coxa origin", w.GroundHeight, w.MinReach, w.MaxReach)
+	}
+
+	return nil
+}
+
+func (s *Shell) executeMaxStrideCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	var limit robot.StrideLimit
+	var err error
+	unit := "mm"
+
+	if len(args) >= 2 && args[1] == "angle" {
+		if len(args) > 3 || (len(args) == 3 && args[2] != "+" && args[2] != "-") {
+			return fmt.Errorf("syntax error ('max_stride <x> <y> | max_stride angle [+|-]'): %+v", args)
+		}
+		direction := 1.0
+		if len(args) == 3 && args[2] == "-" {
+			direction = -1.0
+		}
+		unit = "degrees"
+		limit, err = s.Pod.MaxStrideRotation(direction)
+	} else {
+		if len(args) != 3 {
+			return fmt.Errorf("syntax error ('max_stride <x> <y> | max_stride angle [+|-]'): %+v", args)
+		}
+		x, xerr := strconv.ParseFloat(args[1], 64)
+		y, yerr := strconv.ParseFloat(args[2], 64)
+		if xerr != nil || yerr != nil {
+			return fmt.Errorf("syntax error ('max_stride <x> <y> | max_stride angle [+|-]'): %+v", args)
+		}
+		limit, err = s.Pod.MaxStrideVector(x, y)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.outputCh <- fmt.Sprintf("Maximum stride: %2.2f %s (gait: %s, zlift: %2.2f)", limit.Stride, unit, s.Pod.BodyDefinition.Gait.Name, s.Pod.BodyDefinition.Gait.Lift())
+	if limit.Leg < 0 {
+		s.outputCh <- "\tNo leg limits the stride within the search range"
+	} else {
+		s.outputCh <- fmt.Sprintf("\tLimited by leg %d: %s", limit.Leg, limit.Err.Error())
+	}
+
+	return nil
+}
+
+// warnIfUnstable warns if the pod would fall over at any point in the gait cycle
+// using the current gait and stride
+func (s *Shell) warnIfUnstable() {
+	if !s.Pod.HasDefinedStride() || s.Pod.BodyDefinition.Gait == nil {
+		return
+	}
+	prediction := s.Pod.PredictStability()
+	if !prediction.Stability.IsStable() {
+		s.outputCh <- fmt.Sprintf("WARNING: The pod is statically unstable with this gait and stride (stability margin %2.2f mm at gait phase %.2f, legs in stance: %v)",
+			prediction.Stability.Margin, prediction.Phase, prediction.Stability.StanceLegs)
+	}
+}
+
+func (s *Shell) executeStabilityCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 1 {
+		return fmt.Errorf("syntax error ('stability'): %+v", args)
+	}
+
+	current := s.Pod.Stability
+	c := current.CenterOfMass
+	s.outputCh <- fmt.Sprintf("Centre of mass: (%2.2f, %2.2f, %2.2f)", c.X, c.Y, c.Z)
+	s.outputCh <- fmt.Sprintf("Legs in stance: %v", current.StanceLegs)
+	s.outputCh <- fmt.Sprintf("Stability margin: %2.2f mm", current.Margin)
+
+	if s.Pod.HasDefinedStride() && s.Pod.BodyDefinition.Gait != nil {
+		prediction := s.Pod.PredictStability()
+		s.outputCh <- fmt.Sprintf("Lowest stability margin in the gait cycle: %2.2f mm (gait phase %.2f, legs in stance: %v)",
+			prediction.Stability.Margin, prediction.Phase, prediction.Stability.StanceLegs)
+		if !prediction.Stability.IsStable() {
+			s.outputCh <- "WARNING: The pod is statically unstable with the current gait and stride"
+		}
+	}
+
+	return nil
+}
+
+func (s *Shell) executeMassCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 1 {
+		return fmt.Errorf("syntax error ('mass'): %+v", args)
+	}
+
+	masses := s.Pod.BodyDefinition.Masses
+	if masses == nil {
+		s.outputCh <- "No mass properties defined. The centre of mass is assumed to be the body origin"
+		return nil
+	}
+
+	c := s.Pod.CenterOfMass()
+	s.outputCh <- fmt.Sprintf("Total mass: %2.2f g (body %2.2f g, payload %2.2f g)", s.Pod.TotalMass(), masses.Body, masses.Payload)
+	s.outputCh <- fmt.Sprintf("Centre of mass: (%2.2f, %2.2f, %2.2f)", c.X, c.Y, c.Z)
+
+	return nil
+}
+
+func (s *Shell) executePayloadCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 5 {
+		return fmt.Errorf("syntax error ('payload <grams> <x> <y> <z>'): %+v", args)
+	}
+
+	var values [4]float64
+	for i := range values {
+		v, err := strconv.ParseFloat(args[i+1], 64)
+		if err != nil {
+			return fmt.Errorf("syntax error ('payload <grams> <x> <y> <z>'): %+v", args)
+		}
+		values[i] = v
+	}
+	if values[0] < 0 {
+		return fmt.Errorf("the payload mass can not be negative")
+	}
+
+	if s.Pod.BodyDefinition.Masses == nil {
+		s.Pod.BodyDefinition.Masses = &robot.MassProperties{}
+	}
+	s.Pod.BodyDefinition.Masses.Payload = values[0]
+	s.Pod.BodyDefinition.Masses.PayloadPosition = robot.NewCoordinate(values[1], values[2], values[3])
+	s.Pod.Stability = s.Pod.CalculateStability()
+
+	return s.executeMassCmd([]string{"mass"})
+}
+
+func (s *Shell) executeTorqueCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 1 && len(args) != 2 {
+		return fmt.Errorf("syntax error ('torque [csv file]'): %+v", args)
+	}
+
+	report, err := s.Pod.EstimateTorques()
+	if err != nil {
+		return err
+	}
+
+	s.outputCh <- fmt.Sprintf("Static joint torques (Nm) over %d ticks. XL-320 stall torque: %2.2f Nm, AX-12 stall torque: %2.2f Nm", len(report.Samples), robot.XL320_STALL_TORQUE, robot.AX12_STALL_TORQUE)
+	for l := range report.Peak {
+		for j := range report.Peak[l] {
+			servo := "XL-320"
+			if report.Peak[l][j] > robot.AX12_STALL_TORQUE {
+				servo = "exceeds AX-12"
+			} else if report.Peak[l][j] > robot.XL320_STALL_TORQUE {
+				servo = "AX-12"
+			}
+			s.outputCh <- fmt.Sprintf("\tLeg %d %s: peak %2.3f, rms %2.3f (%s)", l, robot.JointName(j), report.Peak[l][j], report.RMS[l][j], servo)
+		}
+	}
+
+	if len(args) == 2 {
+		exists, err := folderExists(fmt.Sprintf("./%s", TORQUE_FOLDER))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			err := os.Mkdir(TORQUE_FOLDER, 0755)
+			if err != nil {
+				return err
+			}
+		}
+		err = report.Export(fmt.Sprintf("./%s/%s", TORQUE_FOLDER, args[1]))
+		if err != nil {
+			return err
+		}
+		s.outputCh <- fmt.Sprintf("Torques exported to : ./%s/%s", TORQUE_FOLDER, args[1])
+	}
+
+	return nil
+}
+
+func (s *Shell) executeLimitsCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 1 {
+		return fmt.Errorf("syntax error ('limits'): %+v", args)
+	}
+
+	s.outputCh <- "Joint limits (degrees):"
+	for _, l := range s.Pod.Legs {
+		line := fmt.Sprintf("Leg %d:", l.Index)
+		for j := 0; j < l.NumServos(); j++ {
+			if j > 0 {
+				line += ","
+			}
+			line += fmt.Sprintf(" %s %s", robot.JointName(j), l.JointLimits.Get(j).String())
+		}
+		s.outputCh <- line
+	}
+
+	return nil
+}
+
+func (s *Shell) executeSetLimitCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 5 && len(args) != 6 {
+		return fmt.Errorf("syntax error ('set_limit <ALL | legNum> <coxa|femur|tibia> <min> <max> [max deg/s]'): %+v", args)
+	}
+
+	var limit robot.JointLimit
+	var err error
+	limit.Min, err = strconv.ParseFloat(args[3], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('set_limit <ALL | legNum> <coxa|femur|tibia> <min> <max> [max deg/s]'): %+v", args)
+	}
+	limit.Max, err = strconv.ParseFloat(args[4], 64)
+	if err != nil || limit.Max < limit.Min {
+		return fmt.Errorf("syntax error ('set_limit <ALL | legNum> <coxa|femur|tibia> <min> <max> [max deg/s]'): %+v", args)
+	}
+	if len(args) == 6 {
+		limit.MaxSpeed, err = strconv.ParseFloat(args[5], 64)
+		if err != nil || limit.MaxSpeed < 0 {
+			return fmt.Errorf("syntax error ('set_limit <ALL | legNum> <coxa|femur|tibia> <min> <max> [max deg/s]'): %+v", args)
+		}
+	}
+
+	legs := []int{}
+	if strings.ToUpper(args[1]) == "ALL" {
+		for _, l := range s.Pod.Legs {
+			legs = append(legs, l.Index)
+		}
+	} else {
+		legnum, err := strconv.ParseInt(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("syntax error ('set_limit <ALL | legNum> <coxa|femur|tibia> <min> <max> [max deg/s]'): %+v", args)
+		}
+		if legnum < 0 || legnum >= int64(s.Pod.BodyDefinition.NumLegs) {
+			return fmt.Errorf("invalid leg index. (Pod has %d legs. Indexing is 0 based)", s.Pod.BodyDefinition.NumLegs)
+		}
+		legs = append(legs, int(legnum))
+	}
+
+	for _, legnum := range legs {
+		limits := s.Pod.BodyDefinition.GetJointLimits(legnum)
+		switch args[2] {
+		case "coxa":
+			limits.Coxa = limit
+		case "femur":
+			limits.Femur = limit
+		case "tibia":
+			limits.Tibia = limit
+		case "tarsus":
+			limits.Set(3, limit)
+		default:
+			// Joints may also be given by their index in the kinematic chain
+			joint, err := strconv.ParseInt(args[2], 10, 32)
+			if err != nil || joint < 0 || int(joint) >= s.Pod.Legs[legnum].NumServos() {
+				return fmt.Errorf("syntax error ('set_limit <ALL | legNum> <coxa|femur|tibia> <min> <max> [max deg/s]'): %+v", args)
+			}
+			limits.Set(int(joint), limit)
+		}
+		err := s.Pod.SetJointLimits(legnum, limits)
+		if err != nil {
+			return err
+		}
+		s.outputCh <- fmt.Sprintf("Changing %s limits of leg %d to %s", args[2], legnum, limit.String())
+	}
+
+	return nil
+}
+
+func (s *Shell) executeKneeCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 3 {
+		return fmt.Errorf("syntax error ('knee <ALL | legNum> <up|down>'): %+v", args)
+	}
+
+	var knee robot.KneeConfiguration
+	switch args[2] {
+	case "up":
+		knee = robot.KneeUp
+	case "down":
+		knee = robot.KneeDown
+	default:
+		return fmt.Errorf("syntax error ('knee <ALL | legNum> <up|down>'): %+v", args)
+	}
+
+	if strings.ToUpper(args[1]) == "ALL" {
+		for _, l := range s.Pod.Legs {
+			err := s.Pod.SetKneeConfiguration(l.Index, knee)
+			if err != nil {
+				return err
+			}
+			s.outputCh <- fmt.Sprintf("Changing knee configuration of leg %d to %s", l.Index, knee)
+		}
+	} else {
+		legnum, err := strconv.ParseInt(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("syntax error ('knee <ALL | legNum> <up|down>'): %+v", args)
+		}
+		if legnum < 0 || legnum >= int64(s.Pod.BodyDefinition.NumLegs) {
+			return fmt.Errorf("invalid leg index. (Pod has %d legs. Indexing is 0 based)", s.Pod.BodyDefinition.NumLegs)
+		}
+		s.outputCh <- fmt.Sprintf("Changing knee configuration of leg %d to %s", legnum, knee)
+		return s.Pod.SetKneeConfiguration(int(legnum), knee)
+	}
+	return nil
+}
+
+func (s *Shell) executeEffectorsCmd(args []string) error {
+	s.outputCh <- "Current end effector positions:"
+
+	positions := s.Pod.GetEndEffectorPositions()
+	for _, p := range positions {
+		s.outputCh <- p.String()
+	}
+
+	return nil
+}
+
+func (s *Shell) executeSetCoxaLengthCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 3 {
+		return fmt.Errorf("syntax error ('set_coxa_length <legnum> <length>'): %+v", args)
+	}
+
+	length, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('set_coxa_length <legnum> <length>'): %+v", args)
+	}
+
+	if strings.ToUpper(args[1]) == "ALL" {
+		for _, l := range s.Pod.Legs {
+			s.Pod.SetCoxaLength(l.Index, length)
+			s.outputCh <- fmt.Sprintf("Changing coxa length of leg %d to %2.2f", l.Index, length)
+		}
+	} else {
+		legnum, err := strconv.ParseInt(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("syntax error ('set_coxa_length <legnum> <length>'): %+v", args)
+		}
+		if legnum < 0 || legnum >= int64(s.Pod.BodyDefinition.NumLegs) {
+			return fmt.Errorf("invalid leg index. (Pod has %d legs. Indexing is 0 based)", s.Pod.BodyDefinition.NumLegs)
+		}
+		s.outputCh <- fmt.Sprintf("Changing coxa length of leg %d to %2.2f", legnum, length)
+		s.Pod.SetCoxaLength(int(legnum), length)
+	}
+	return nil
+}
+
+func (s *Shell) executeSetFemurLengthCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+	if len(args) != 3 {
+		return fmt.Errorf("syntax error ('set_femur_length <legnum> <length>'): %+v", args)
+	}
+
+	length, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('set_femur_length <legnum> <length>'): %+v", args)
+	}
+
+	if strings.ToUpper(args[1]) == "ALL" {
+		for _, l := range s.Pod.Legs {
+			s.Pod.SetFemurLength(l.Index, length)
+			s.outputCh <- fmt.Sprintf("Changing femur length of leg %d to %2.2f", l.Index, length)
+		}
+	} else {
+		legnum, err := strconv.ParseInt(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("syntax error ('set_femur_length <legnum> <length>'): %+v", args)
+		}
+		if legnum < 0 || legnum >= int64(s.Pod.BodyDefinition.NumLegs) {
+			return fmt.Errorf("invalid leg index. (Pod has %d legs. Indexing is 0 based)", s.Pod.BodyDefinition.NumLegs)
+		}
+		s.outputCh <- fmt.Sprintf("Changing femur length of leg %d to %2.2f", legnum, length)
+		s.Pod.SetFemurLength(int(legnum), length)
+	}
+
+	return nil
+}
+
+func (s *Shell) executeSetTibiaLengthCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+	if len(args) != 3 {
+		return fmt.Errorf("syntax error ('set_tibia_length <legnum> <length>'): %+v", args)
+	}
+
+	length, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('set_tibia_length <legnum> <length>'): %+v", args)
+	}
+
+	if strings.ToUpper(args[1]) == "ALL" {
+		for _, l := range s.Pod.Legs {
+			s.Pod.SetTibiaLength(l.Index, length)
+			s.outputCh <- fmt.Sprintf("Changing tibia length of leg %d to %2.2f", l.Index, length)
+		}
+	} else {
+		legnum, err := strconv.ParseInt(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("syntax error ('set_tibia_length <legnum> <length>'): %+v", args)
+		}
+		if legnum < 0 || legnum >= int64(s.Pod.BodyDefinition.NumLegs) {
+			return fmt.Errorf("invalid leg index. (Pod has %d legs. Indexing is 0 based)", s.Pod.BodyDefinition.NumLegs)
+		}
+		s.outputCh <- fmt.Sprintf("Changing tibia length to %2.2f", length)
+		s.Pod.SetTibiaLength(int(legnum), length)
+	}
+	return nil
+}
+
+func (s *Shell) executeSetCoxaAngleCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+	if len(args) != 3 {
+		return fmt.Errorf("syntax error ('set_coxa_angle <legnum> <angle>'): %+v", args)
+	}
+
+	angle, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('set_coxa_angle  <legnum> <angle>'): %+v", args)
+	}
+
+	if strings.ToUpper(args[1]) == "ALL" {
+		for _, l := range s.Pod.Legs {
+			err := s.Pod.SetCoxaAngle(l.Index, angle)
+			if err != nil {
+				return err
+			}
+			s.outputCh <- fmt.Sprintf("Changing coxa angle of leg %d to %2.2f", l.Index, angle)
+		}
+	} else {
+		legnum, err := strconv.ParseInt(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("syntax error ('set_coxa_angle  <legnum> <angle>'): %+v", args)
+		}
+		if legnum < 0 || legnum >= int64(s.Pod.BodyDefinition.NumLegs) {
+			return fmt.Errorf("invalid leg index. (Pod has %d legs. Indexing is 0 based)", s.Pod.BodyDefinition.NumLegs)
+		}
+		s.outputCh <- fmt.Sprintf("Changing coxa angle of leg %d to %2.2f", legnum, angle)
+		return s.Pod.SetCoxaAngle(int(legnum), angle)
+	}
+	return nil
+}
+
+func (s *Shell) executeSetFemurAngleCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+	if len(args) != 3 {
+		return fmt.Errorf("syntax error ('set_femur_angle  <legnum> <angle>'): %+v", args)
+	}
+
+	angle, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('set_femur_angle  <legnum> <angle>'): %+v", args)
+	}
+
+	if strings.ToUpper(args[1]) == "ALL" {
+		for _, l := range s.Pod.Legs {
+			err := s.Pod.SetFemurAngle(l.Index, angle)
+			if err != nil {
+				return err
+			}
+			s.outputCh <- fmt.Sprintf("Changing femur angle of leg %d to %2.2f", l.Index, angle)
+		}
+	} else {
+		legnum, err := strconv.ParseInt(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("syntax error ('set_femur_angle  <legnum> <angle>'): %+v", args)
+		}
+		if legnum < 0 || legnum >= int64(s.Pod.BodyDefinition.NumLegs) {
+			return fmt.Errorf("invalid leg index. (Pod has %d legs. Indexing is 0 based)", s.Pod.BodyDefinition.NumLegs)
+		}
+		s.outputCh <- fmt.Sprintf("Changing femur angle of leg %d to %2.2f", legnum, angle)
+		return s.Pod.SetFemurAngle(int(legnum), angle)
+	}
+
+	return nil
+}
+
+func (s *Shell) executeSetTibiaAngleCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+	if len(args) != 3 {
+		return fmt.Errorf("syntax error ('set_tibia_angle <angle>'): %+v", args)
+	}
+
+	angle, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('set_tibia_angle <angle>'): %+v", args)
+	}
+
+	if strings.ToUpper(args[1]) == "ALL" {
+		for _, l := range s.Pod.Legs {
+			err := s.Pod.SetTibiaAngle(l.Index, angle)
+			if err != nil {
+				return err
+			}
+			s.outputCh <- fmt.Sprintf("Changing tibia angle of leg %d to %2.2f", l.Index, angle)
+		}
+	} else {
+		legnum, err := strconv.ParseInt(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("syntax error ('set_tibia_angle <angle>'): %+v", args)
+		}
+		if legnum < 0 || legnum >= int64(s.Pod.BodyDefinition.NumLegs) {
+			return fmt.Errorf("invalid leg index. (Pod has %d legs. Indexing is 0 based)", s.Pod.BodyDefinition.NumLegs)
+		}
+		s.outputCh <- fmt.Sprintf("Changing tibia angle og leg %d to %2.2f", legnum, angle)
+		return s.Pod.SetTibiaAngle(int(legnum), angle)
+	}
+	return nil
+}
+
+func (s *Shell) Dispatch(command string) error {
+	command = strings.TrimSpace(command)
+	for key, value := range s.dispatchMap {
+		if len(command) >= len(key) && command[:len(key)] == key {
+			return value(strings.Split(command, " "))
+		}
+	}
+
+	return fmt.Errorf("unknown command: '%s'", command)
+}
+
+func (s *Shell) executeStrideVectorCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 4 {
+		return fmt.Errorf("syntax error ('stride_vector <nrepeats> <X> <Y>'): %+v", args)
+	}
+
+	repeats, err := strconv.ParseInt(args[1], 10, 32)
+	if err != nil {
+		return fmt.Errorf("syntax error ('stride_vector <nrepeats> <X> <Y>'): %+v", args)
+	}
+
+	deltaX, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('stride_vector <nrepeats> <X> <Y>'): %+v", args)
+	}
+
+	deltaY, err := strconv.ParseFloat(args[3], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('stride_vector <nrepeats> <X> <Y>'): %+v", args)
+	}
+
+	// A walking pod changes stride without stopping
+	if !s.Pod.IsWalking() {
+		s.Pod.ResetInterpolator()
+	}
+
+	err = s.Pod.SetStrideVector(int(repeats), deltaX, deltaY)
+	if err != nil {
+		return err
+	}
+	s.warnIfUnstable()
+	return nil
+}
+
+func (s *Shell) executeStrideMotionCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	syntax := fmt.Errorf("syntax error ('stride_motion <nrepeats> <X> <Y> <degrees> [<pivot X> <pivot Y>]'): %+v", args)
+	if len(args) != 5 && len(args) != 7 {
+		return syntax
+	}
+
+	repeats, err := strconv.ParseInt(args[1], 10, 32)
+	if err != nil {
+		return syntax
+	}
+
+	var values [5]float64
+	for i, arg := range args[2:] {
+		values[i], err = strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return syntax
+		}
+	}
+	motion := robot.StrideMotion{X: values[0], Y: values[1], Degrees: values[2], Pivot: robot.NewCoordinate(values[3], values[4], 0)}
+
+	// A walking pod changes stride without stopping
+	if !s.Pod.IsWalking() {
+		s.Pod.ResetInterpolator()
+	}
+
+	err = s.Pod.SetStrideMotion(int(repeats), motion)
+	if err != nil {
+		return err
+	}
+	s.warnIfUnstable()
+	return nil
+}
+
+func (s *Shell) executeVelocityCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	syntax := fmt.Errorf("syntax error ('velocity <nrepeats> <vx> <vy> [<yaw rate>]'): %+v", args)
+	if len(args) != 4 && len(args) != 5 {
+		return syntax
+	}
+
+	repeats, err := strconv.ParseInt(args[1], 10, 32)
+	if err != nil {
+		return syntax
+	}
+
+	var values [3]float64
+	for i, arg := range args[2:] {
+		values[i], err = strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return syntax
+		}
+	}
+	velocity := robot.Velocity{X: values[0], Y: values[1], Yaw: values[2]}
+
+	// A walking pod changes velocity without stopping
+	if !s.Pod.IsWalking() {
+		s.Pod.ResetInterpolator()
+	}
+
+	err = s.Pod.SetVelocity(int(repeats), velocity)
+	if err != nil {
+		return err
+	}
+
+	gait := s.Pod.NextGait()
+	speed := math.Hypot(velocity.X, velocity.Y)
+	s.outputCh <- fmt.Sprintf("Walking at %2.2f mm/s turning %2.2f deg/s with the %s (stride vector length %2.2f mm)",
+		speed, velocity.Yaw, gait.Name, robot.StrideForSpeed(gait, speed))
+	if s.Pod.IsChangingGait() {
+		s.outputCh <- fmt.Sprintf("Switching to %s at the next compatible phase", gait.Name)
+	}
+	s.warnIfUnstable()
+	return nil
+}
+
+func (s *Shell) executeAutoGaitCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	syntax := fmt.Errorf("syntax error ('auto_gait <on|off> [<ripple speed> <tripod speed> [hysteresis]]'): %+v", args)
+	if len(args) < 2 || len(args) == 3 || len(args) > 5 {
+		return syntax
+	}
+
+	switch args[1] {
+	case "off":
+		if len(args) != 2 {
+			return syntax
+		}
+		s.outputCh <- "Automatic gait selection is off"
+		return s.Pod.SetAutoGait(nil)
+	case "on":
+	default:
+		return syntax
+	}
+
+	autoGait := robot.NewAutoGait()
+	values := []*float64{&autoGait.RippleSpeed, &autoGait.TripodSpeed, &autoGait.Hysteresis}
+	for i, arg := range args[2:] {
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return syntax
+		}
+		*values[i] = v
+	}
+
+	err := s.Pod.SetAutoGait(autoGait)
+	if err != nil {
+		return err
+	}
+	s.outputCh <- fmt.Sprintf("Automatic gait selection: wave below %2.2f mm/s, ripple below %2.2f mm/s, tripod above (hysteresis %2.2f mm/s)",
+		autoGait.RippleSpeed, autoGait.TripodSpeed, autoGait.Hysteresis)
+	return nil
+}
+
+func (s *Shell) executeStrideAngleCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	syntax := fmt.Errorf("syntax error ('stride_angle <nrepeats> <degrees> [<pivot X> <pivot Y>]'): %+v", args)
+	if len(args) != 3 && len(args) != 5 {
+		return syntax
+	}
+
+	repeats, err := strconv.ParseInt(args[1], 10, 32)
+	if err != nil {
+		return syntax
+	}
+
+	var values [3]float64
+	for i, arg := range args[2:] {
+		values[i], err = strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return syntax
+		}
+	}
+
+	// A walking pod changes stride without stopping
+	if !s.Pod.IsWalking() {
+		s.Pod.ResetInterpolator()
+	}
+
+	if len(args) == 5 {
+		err = s.Pod.SetRotationAround(int(repeats), values[0], robot.NewCoordinate(values[1], values[2], 0))
+	} else {
+		err = s.Pod.SetRotation(int(repeats), values[0])
+	}
+	if err != nil {
+		return err
+	}
+	s.warnIfUnstable()
+	return nil
+}
+
+func (s *Shell) executeStartCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 1 {
+		return fmt.Errorf("syntax error ('start'): %+v", args)
+	}
+
+	if !s.Pod.HasDefinedStride() {
+		return fmt.Errorf("no defined stride")
+	}
+	err := s.Pod.Start()
+	s.Network.Start()
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Shell) executeStopCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 1 {
+		return fmt.Errorf("syntax error ('stop'): %+v", args)
+	}
+
+	s.Pod.Stop()
+	return nil
+}
+
+func (s *Shell) executeResetCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('reset <1|2>'): %+v", args)
+	}
+
+	s.Pod.Stop()
+	s.Network.Disconnect()
+
+	// The new pod keeps the tick rate set by the user (see rate)
+	rate := s.Pod.Clock.Rate
+
+	if args[1] == "0" {
+		s.Pod = robot.NewPod(robot.NewExampleHexapod0())
+	} else if args[1] == "1" {
+		s.Pod = robot.NewPod(robot.NewExampleHexapod1())
+	} else if args[1] == "2" {
+		s.Pod = robot.NewPod(robot.NewExampleHexapod2())
+	} else if args[1] == "3" {
+		s.Pod = robot.NewPod(robot.NewExamplePentapod())
+	} else if args[1] == "4" {
+		s.Pod = robot.NewPod(robot.NewHeptapod())
+	} else if args[1] == "5" {
+		s.Pod = robot.NewPod(robot.NewSpider())
+	} else if args[1] == "6" {
+		s.Pod = robot.NewPod(robot.NewTarsusHexapod())
+	} else if args[1] == "7" {
+		s.Pod = robot.NewPod(robot.NewTwoJointHexapod())
+	} else {
+		return fmt.Errorf("Unknown example preset")
+	}
+
+	s.Pod.Update()
+	s.Pod.SetDebugChannel(s.outputCh)
+	s.Network.SetPod(s.Pod)
+
+	return s.Pod.SetTickRate(rate)
+}
+
+func (s *Shell) executeSpeedCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) == 1 {
+		s.outputCh <- fmt.Sprintf("Simulation speed %2.2f x real time", s.Speed)
+		return nil
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('speed [<%2.2f-%2.0f>]'): %+v", MIN_SIMULATION_SPEED, MAX_SIMULATION_SPEED, args)
+	}
+
+	speed, err := strconv.ParseFloat(args[1], 64)
+	if err != nil || speed < MIN_SIMULATION_SPEED || speed > MAX_SIMULATION_SPEED {
+		return fmt.Errorf("syntax error ('speed [<%2.2f-%2.0f>]'): %+v", MIN_SIMULATION_SPEED, MAX_SIMULATION_SPEED, args)
+	}
+
+	s.Speed = speed
+	s.outputCh <- fmt.Sprintf("Simulation speed %2.2f x real time", s.Speed)
+
+	return nil
+}
+
+func (s *Shell) executeRateCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) == 1 {
+		gait := s.Pod.NextGait()
+		s.outputCh <- fmt.Sprintf("%2.2f Hz (%2.2f ms per tick, %s cycle %2.2f s)",
+			s.Pod.Clock.Rate, 1000*s.Pod.Clock.Dt(), gait.Name, float64(gait.CycleTicks())*gait.Dt())
+		return nil
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('rate [<Hz>]'): %+v", args)
+	}
+
+	rate, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('rate [<Hz>]'): %+v", args)
+	}
+	err = s.Pod.SetTickRate(rate)
+	if err != nil {
+		return err
+	}
+	s.outputCh <- fmt.Sprintf("Interpolating and streaming at %2.2f Hz", rate)
+	return nil
+}
+
+func (s *Shell) executeZLiftCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('zlift <0-90>'): %+v", args)
+	}
+
+	zlift, err := strconv.ParseFloat(args[1], 64)
+	if err != nil || zlift < 0 || zlift > 90 {
+		return fmt.Errorf("syntax error ('zlift <0-90>'): %+v", args)
+	}
+
+	robot.Z_LIFT = zlift
+
+	return nil
+}
+
+func (s *Shell) executeGaitCmd(args []string) error {
+	// The gait is switched once the pod has reverted to the neutral stance
+	if s.Pod.IsReverting() {
+		return ErrPodBusy
+	}
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) == 2 && args[1] == "list" {
+		return s.listGaits()
+	}
+	if len(args) == 3 && args[1] == "load" {
+		gait, err := robot.LoadGait(fmt.Sprintf("./%s/%s", GAITS_FOLDER, args[2]), s.Pod.BodyDefinition.NumLegs)
+		if err != nil {
+			return err
+		}
+		s.printGait(gait)
+		return s.setGait(gait)
+	}
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('gait <tripod|ripple|wave|metachronal>', 'gait load <file>' or 'gait list'): %+v", args)
+	}
+
+	gaitType, err := parseGaitType(args[1])
+	if err != nil {
+		return fmt.Errorf("syntax error ('gait <tripod|ripple|wave|metachronal>', 'gait load <file>' or 'gait list'): %+v", args)
+	}
+
+	gait, err := robot.NewGait(s.Pod.BodyDefinition.NumLegs, gaitType)
+	if err != nil {
+		return err
+	}
+	return s.setGait(gait)
+}
+
+// parseGaitType returns the generated gait type with a given name
+func parseGaitType(name string) (robot.GaitType, error) {
+	switch name {
+	case "tripod":
+		return robot.TRIPOD, nil
+	case "ripple":
+		return robot.RIPPLE, nil
+	case "wave":
+		return robot.WAVE, nil
+	case "metachronal":
+		return robot.METACHRONAL, nil
+	}
+	return 0, fmt.Errorf("unknown gait type %s", name)
+}
+
+// setGait switches to a new gait and starts walking. A walking pod switches
+// gait at the next compatible phase of the current gait
+func (s *Shell) setGait(gait *robot.Gait) error {
+	// A manually selected gait overrides automatic gait selection
+	if s.Pod.AutoGait != nil {
+		s.Pod.SetAutoGait(nil)
+		s.outputCh <- "Automatic gait selection is off"
+	}
+
+	err := s.Pod.SetGait(gait)
+	if err != nil {
+		return err
+	}
+	if s.Pod.IsChangingGait() {
+		s.outputCh <- fmt.Sprintf("Switching to %s at the next compatible phase", gait.Name)
+	}
+	s.Pod.SetDebugChannel(s.outputCh)
+
+	s.warnIfUnstable()
+	s.Pod.Start()
+	s.Network.Start()
+
+	return nil
+}
+
+// listGaits outputs the gait files in the gaits folder
+func (s *Shell) listGaits() error {
+	entries, err := os.ReadDir(fmt.Sprintf("./%s", GAITS_FOLDER))
+	if os.IsNotExist(err) {
+		s.outputCh <- fmt.Sprintf("No gait files found (the ./%s folder does not exist)", GAITS_FOLDER)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.outputCh <- fmt.Sprintf("Gait files in ./%s:", GAITS_FOLDER)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		gait, err := robot.LoadGait(fmt.Sprintf("./%s/%s", GAITS_FOLDER, entry.Name()), s.Pod.BodyDefinition.NumLegs)
+		if err != nil {
+			s.outputCh <- fmt.Sprintf("\t%s (unusable: %s)", entry.Name(), err)
+			continue
+		}
+		s.outputCh <- fmt.Sprintf("\t%s - %s (duty factor %2.2f)", entry.Name(), gait.Name, gait.DutyFactor)
+	}
+	return nil
+}
+
+// printGait outputs the duty factor, lift height and the phase offset of each leg for a gait
+func (s *Shell) printGait(gait *robot.Gait) {
+	s.outputCh <- fmt.Sprintf("%s: duty factor %2.3f, lift height %2.2f", gait.Name, gait.DutyFactor, gait.Lift())
+	for l, offset := range gait.PhaseOffsets {
+		s.outputCh <- fmt.Sprintf("\tLeg %d: phase offset %2.3f, swing %s", l, offset, gait.LegSwingProfile(l))
+	}
+}
+
+// tuneGait replaces the current gait with a modified copy. The pod keeps walking,
+// and switches to the modified gait at the next compatible phase
+func (s *Shell) tuneGait(modify func(gait *robot.Gait)) error {
+	gait := *s.Pod.NextGait()
+	gait.PhaseOffsets = append([]float64{}, gait.PhaseOffsets...)
+	modify(&gait)
+
+	err := s.Pod.SetGait(&gait)
+	if err != nil {
+		return err
+	}
+
+	s.printGait(&gait)
+	s.warnIfUnstable()
+	return nil
+}
+
+func (s *Shell) executeDutyCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('duty <duty factor>'): %+v", args)
+	}
+	dutyFactor, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('duty <duty factor>'): %+v", args)
+	}
+
+	return s.tuneGait(func(gait *robot.Gait) {
+		gait.DutyFactor = dutyFactor
+	})
+}
+
+func (s *Shell) executePhaseCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) == 1 {
+		s.printGait(s.Pod.NextGait())
+		return nil
+	}
+	if len(args) != 3 {
+		return fmt.Errorf("syntax error ('phase [<legNum> <offset>]'): %+v", args)
+	}
+	legNum, err := strconv.Atoi(args[1])
+	if err != nil || legNum < 0 || legNum >= s.Pod.BodyDefinition.NumLegs {
+		return fmt.Errorf("invalid leg index. (Pod has %d legs. Indexing is 0 based)", s.Pod.BodyDefinition.NumLegs)
+	}
+	offset, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('phase [<legNum> <offset>]'): %+v", args)
+	}
+
+	return s.tuneGait(func(gait *robot.Gait) {
+		gait.PhaseOffsets[legNum] = offset
+	})
+}
+
+func (s *Shell) executeSwingCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	syntax := fmt.Errorf("syntax error ('swing [<ALL | legNum> <sine|cycloid|quintic|rectangular|bezier> [<height> [<lift-off speed> <touchdown speed>]] [<progress>,<height> ...]]'): %+v", args)
+	if len(args) == 1 {
+		gait := s.Pod.NextGait()
+		for l := range s.Pod.Legs {
+			s.outputCh <- fmt.Sprintf("\tLeg %d: %s", l, gait.LegSwingProfile(l))
+		}
+		return nil
+	}
+	if len(args) < 3 {
+		return syntax
+	}
+
+	legNum := -1
+	if args[1] != "ALL" {
+		var err error
+		legNum, err = strconv.Atoi(args[1])
+		if err != nil || legNum < 0 || legNum >= s.Pod.BodyDefinition.NumLegs {
+			return fmt.Errorf("invalid leg index. (Pod has %d legs. Indexing is 0 based)", s.Pod.BodyDefinition.NumLegs)
+		}
+	}
+
+	profileType, err := robot.ParseSwingProfileType(args[2])
+	if err != nil {
+		return err
+	}
+	profile := &robot.SwingProfile{Type: profileType}
+
+	// Numbers (height and speeds) come first, followed by the bezier control points
+	var values []float64
+	for _, arg := range args[3:] {
+		if progress, height, found := strings.Cut(arg, ","); found {
+			var c robot.SwingPoint
+			c.Progress, err = strconv.ParseFloat(progress, 64)
+			if err != nil {
+				return syntax
+			}
+			c.Height, err = strconv.ParseFloat(height, 64)
+			if err != nil {
+				return syntax
+			}
+			profile.ControlPoints = append(profile.ControlPoints, c)
+			continue
+		}
+		if len(profile.ControlPoints) > 0 {
+			return syntax
+		}
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return syntax
+		}
+		values = append(values, v)
+	}
+	switch len(values) {
+	case 3:
+		profile.LiftOffSpeed = &values[1]
+		profile.TouchdownSpeed = &values[2]
+		fallthrough
+	case 1:
+		profile.Height = values[0]
+	case 0:
+	default:
+		return syntax
+	}
+
+	return s.tuneGait(func(gait *robot.Gait) {
+		if legNum < 0 {
+			gait.SwingProfile = profile
+			gait.LegSwingProfiles = nil
+			return
+		}
+		profiles := make([]*robot.SwingProfile, s.Pod.BodyDefinition.NumLegs)
+		copy(profiles, gait.LegSwingProfiles)
+		profiles[legNum] = profile
+		gait.LegSwingProfiles = profiles
+	})
+}
+
+func (s *Shell) executeOpenServoPortCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('open_servo_port <IP:port>'): %+v", args)
+	}
+
+	if strings.Contains(args[1], ":") {
+		s.outputCh <- fmt.Sprintf("Opening connection to %s", args[1])
+		err := s.Network.Dial(args[1])
+		if err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("syntax error ('open_servo_port <IP:port>'): %+v", args)
+	}
+
+	return nil
+}
+
+func (s *Shell) executeCloseServoPortCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 1 {
+		return fmt.Errorf("syntax error ('close_servo_port'): %+v", args)
+	}
+
+	s.Network.Disconnect()
+
+	return nil
+}
+
+func (s *Shell) executeSaveCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	exists, err := folderExists(fmt.Sprintf("./%s", POD_FOLDER))
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		err := os.Mkdir(fmt.Sprintf("%s", POD_FOLDER), 0755)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('save <filename>'): %+v", args)
+	}
+
+	return s.Pod.BodyDefinition.Save(fmt.Sprintf("./%s/%s", POD_FOLDER, args[1]))
+}
+
+func (s *Shell) executeLoadCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('load <filename>'): %+v", args)
+	}
+
+	definition, err := s.Pod.BodyDefinition.Load(fmt.Sprintf("./%s/%s", POD_FOLDER, args[1]))
+	if err != nil {
+		return err
+	}
+
+	s.Pod.LoadBodyDefinition(definition)
+	s.Pod.UpdatePodStructure()
+
+	return nil
+}
+
+func (s *Shell) executeZeroCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+	s.Pod.Zero()
+
+	s.Network.Start()
+
+	return nil
+}
+
+func (s *Shell) executeReverseCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	s.Pod.ReverseDirection()
+	return nil
+}
+
+func (s *Shell) executeRevertCmd(args []string) error {
+	// The pod reverts once the body has reached its new pose (see robot.Posing)
+	if s.Pod.State() == robot.Posing {
+		return ErrPodBusy
+	}
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	s.Pod.ResetInterpolator()
+	err := s.Pod.RevertToNutral()
+	if err != nil {
+		return err
+	}
+
+	// TODO: refactor
+	if s.Pod.IsRecording {
+		for _, l := range s.Pod.Legs {
+			s.Pod.MotionPrimitive.Add(l.ServoAngles)
+		}
+	}
+
+	return nil
+}
+
+func (s *Shell) executeRecordCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) == 3 && args[1] == "on" {
+		steps, err := strconv.Atoi(args[2])
+		if err != nil {
+			return fmt.Errorf("syntax error ('record on [steps]'): %+v", args)
+		}
+		err = s.Pod.SetInterpolationSteps(steps)
+		if err != nil {
+			return err
+		}
+		s.outputCh <- fmt.Sprintf("Recording with %d interpolation steps. Define the stride before starting", steps)
+		args = args[:2]
+	}
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('record <on [steps]|off>'): %+v", args)
+	}
+
+	if args[1] == "on" {
+		s.Pod.StartRecording()
+		s.outputCh <- fmt.Sprintf("Recording at %2.2f Hz", s.Pod.MotionPrimitive.SampleRate)
+	} else if args[1] == "off" {
+		s.Pod.IsRecording = false
+		s.Pod.ResetTicks()
+		s.Pod.ClearPrimitives()
+	} else {
+		return fmt.Errorf("syntax error ('record <on|off>'): %+v", args)
+	}
+	return nil
+}
+
+func (s *Shell) executeInterpolationCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) == 1 {
+		s.outputCh <- fmt.Sprintf("%d interpolation steps (the %s swings in %d ticks)", s.Pod.InterpolationSteps, s.Pod.NextGait().Name, s.Pod.NextGait().Steps())
+		return nil
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('interpolation [<steps>]'): %+v", args)
+	}
+
+	steps, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("syntax error ('interpolation [<steps>]'): %+v", args)
+	}
+	err = s.Pod.SetInterpolationSteps(steps)
+	if err != nil {
+		return err
+	}
+	s.outputCh <- fmt.Sprintf("%d interpolation steps. New strides and body poses use the new number of steps", steps)
+	return nil
+}
+
+func folderExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (s *Shell) executeExportCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 4 {
+		return fmt.Errorf("syntax error ('export <filename> <servo range> <mask>'): %+v", args)
+	}
+
+	servoRange, err := strconv.ParseInt(args[2], 10, 32)
+	if err != nil {
+		return fmt.Errorf("syntax error ('export <filename> <servo range>' <mask>): %+v", args)
+	}
+
+	exists, err := folderExists(fmt.Sprintf("./%s", PRIMITIVES_FOLDER))
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		err := os.Mkdir(fmt.Sprintf("%s", PRIMITIVES_FOLDER), 0755)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(args[3]) == 0 {
+		return fmt.Errorf("invalid bit mask: %+v", args[3])
+	}
+
+	// One bit per joint in kinematic chain order (coxa, femur, tibia, ...)
+	maskOk := true
+	var inverted []bool
+	for _, bit := range args[3] {
+		if bit != '0' && bit != '1' {
+			maskOk = false
+		}
+		inverted = append(inverted, bit == '1')
+	}
+	if !maskOk {
+		return fmt.Errorf("invalid bit mask: %+v", args[3])
+	}
+
+	if !s.Pod.IsRecording {
+		return fmt.Errorf("no recording started. nothing to export")
+	}
+
+	joints := make([]int, len(s.Pod.Legs))
+	for i, l := range s.Pod.Legs {
+		joints[i] = l.NumServos()
+	}
+	s.Pod.MotionPrimitive.Export(fmt.Sprintf("./%s/%s", PRIMITIVES_FOLDER, args[1]), int(servoRange), inverted, joints)
+
+	s.outputCh <- fmt.Sprintf("Servoangles normalized for %d degrees", servoRange)
+	s.outputCh <- "\tMidpoint equals raw value 512"
+	s.outputCh <- fmt.Sprintf("\tnegative %d degrees equals raw value 0", servoRange/2)
+	s.outputCh <- fmt.Sprintf("\tpositive %d degrees equals raw value 1024", servoRange/2)
+	s.outputCh <- fmt.Sprintf("Recording exported to : ./%s/%s", PRIMITIVES_FOLDER, args[1])
+	samples := s.Pod.MotionPrimitive.Size() / len(s.Pod.Legs)
+	s.outputCh <- fmt.Sprintf("\t%d samples at %2.2f Hz (%2.2f s)", samples, s.Pod.MotionPrimitive.SampleRate, float64(samples)/s.Pod.MotionPrimitive.SampleRate)
+
+	s.Pod.ClearPrimitives()
+
+	return nil
+}
+
+func (s *Shell) executeDebugCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 1 {
+		return fmt.Errorf("syntax error ('debug'): %+v", args)
+	}
+
+	s.Pod.Debug(fmt.Sprintf("Motion set size: %d", s.Pod.MotionPrimitive.Size()))
+
+	return nil
+}
+
+func (s *Shell) executeStepCycleCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if !s.Pod.HasDefinedStride() {
+		return fmt.Errorf("no defined stride")
+	}
+
+	s.Pod.AddTargetGaitCycles(1)
+	return s.Pod.Start()
+}
+
+func (s *Shell) executeStateCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 1 {
+		return fmt.Errorf("syntax error ('state'): %+v", args)
+	}
+
+	s.outputCh <- fmt.Sprintf("State: %s (gait cycle %d, tick %d)", s.Pod.State(), s.Pod.GetCurrentGaitCycle(), s.Pod.Clock.Ticks)
+	if s.Pod.IsPosing() {
+		s.outputCh <- "The body is moving to a new pose"
+	}
+	return nil
+}
+
+func (s *Shell) executeEventsCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+		return fmt.Errorf("syntax error ('events <on|off>'): %+v", args)
+	}
+
+	s.ShowEvents = args[1] == "on"
+	return nil
+}
+
+func (s *Shell) executePitchCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('pitch <degrees>'): %+v", args)
+	}
+
+	degrees, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('pitch <degrees>'): %+v", args)
+	}
+
+	pose := s.Pod.BodyPose
+	pose.Rotation.Y += degrees
+
+	return s.moveBody(pose)
+}
+
+func (s *Shell) executeYawCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('yaw <degrees>'): %+v", args)
+	}
+
+	degrees, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('yaw <degrees>'): %+v", args)
+	}
+
+	pose := s.Pod.BodyPose
+	pose.Rotation.Z += degrees
+
+	return s.moveBody(pose)
+}
+
+func (s *Shell) executeRollCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('roll <degrees>'): %+v", args)
+	}
+
+	degrees, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('roll <degrees>'): %+v", args)
+	}
+
+	pose := s.Pod.BodyPose
+	pose.Rotation.X += degrees
+
+	return s.moveBody(pose)
+}
+
+func (s *Shell) executeUpCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('up <z>'): %+v", args)
+	}
+
+	z, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('up <z>'): %+v", args)
+	}
+
+	// Positive Z points towards the ground
+	pose := s.Pod.BodyPose
+	pose.Translation.Z -= z
+
+	return s.moveBody(pose)
+}
+
+func (s *Shell) executeDownCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('down <z>'): %+v", args)
+	}
+
+	z, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('down <z>'): %+v", args)
+	}
+
+	// Positive Z points towards the ground
+	pose := s.Pod.BodyPose
+	pose.Translation.Z += z
+
+	return s.moveBody(pose)
+}
+
+func (s *Shell) executeShiftCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 3 {
+		return fmt.Errorf("syntax error ('shift <x> <y>'): %+v", args)
+	}
+
+	x, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('shift <x> <y>'): %+v", args)
+	}
+
+	y, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('shift <x> <y>'): %+v", args)
+	}
+
+	pose := s.Pod.BodyPose
+	pose.Translation.X += x
+	pose.Translation.Y += y
+
+	return s.moveBody(pose)
+}
+
+func (s *Shell) executePoseCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) == 2 && args[1] == "reset" {
+		return s.moveBody(robot.BodyPose{})
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("syntax error ('pose [reset]'): %+v", args)
+	}
+
+	s.outputCh <- fmt.Sprintf("Body rotation (roll, pitch, yaw): %s", s.Pod.BodyPose.Rotation.String())
+	s.outputCh <- fmt.Sprintf("Body translation:                 %s", s.Pod.BodyPose.Translation.String())
+
+	return nil
+}
+
+// moveBody solves the body IK equations for the new pose and lets the pod
+// move the body through the interpolator (and stream the result)
+func (s *Shell) moveBody(pose robot.BodyPose) error {
+	err := s.Pod.SetBodyPose(pose)
+	if err != nil {
+		return err
+	}
+
+	s.Network.Start()
+	return nil
+}
+
+func (s *Shell) executeGroundCmd(args []string) error {
+	s.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('ground <height>'): %+v", args)
+	}
+
+	height, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return fmt.Errorf("syntax error ('ground <height>'): %+v", args)
+	}
+
+	return s.Pod.Ground(height)
+}
@@ -0,0 +1,384 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"GOIK/robot"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+	Notes regarding the engine
+
+	1) The engine is the pod, the simulation clock, the network output and the command shell without
+	   any user interface or display. The simulator drives the engine in real time from the render loop,
+	   while programs, tests and the headless mode step it as fast as possible.
+	2) Every tick moves the pod one step (see Pod.Update) and streams the servo angles to the robot
+	   (if a connection is open and streaming has started).
+	3) The pod is only accessed from the goroutine running the simulation loop (the ebiten Update/Draw loop
+	   in the simulator). Other goroutines (the command console, network clients etc.) send commands as
+	   messages (see Submit and Send). The loop applies them between ticks (see ApplyCommands) and signals
+	   the result back to the sender, so the loop never moves or draws a half updated pod.
+	4) A command that has to wait for the pod (see ErrPodBusy) stays at the head of the queue, and is applied
+	   again after the next tick. Later commands wait behind it, so the commands are applied in order.
+	5) The events of the pod (state changes, completed gait cycles etc, see robot.PodEvent) are sent to the
+	   subscribers (see Subscribe), so other goroutines can wait for the pod instead of polling its state.
+	6) A sender may also wait until the pod has completed the motion started by a command, or until
+	   a duration of simulated time has passed (see SendAndWait and Wait). The loop keeps these requests
+	   and completes them after the tick that fulfils them, which is how scripts run (see script.go).
+*/
+
+// Range of the simulation speed (simulated time per real time, see Advance)
+const MIN_SIMULATION_SPEED = 0.1
+const MAX_SIMULATION_SPEED = 10.0
+
+// Longest (simulated) time the headless mode waits for the pod to complete a command
+const HEADLESS_TIMEOUT = 60 * time.Second
+
+// Interval between updates of an engine running in real time
+const REAL_TIME_INTERVAL = 5 * time.Millisecond
+
+// Number of commands that can be queued before Submit blocks
+const COMMAND_QUEUE_SIZE = 16
+
+// Number of events that can wait for a subscriber before events are dropped
+const EVENT_QUEUE_SIZE = 64
+
+// request is a shell command waiting to be applied by the simulation loop
+type request struct {
+	command string
+	// Wait for the pod to complete the motion started by the command (see SendAndWait)
+	motion bool
+	// Simulated time to wait once the command has been applied (see Wait)
+	duration time.Duration
+	// Receives the result of the command once it has been applied
+	done chan error
+}
+
+// waiter is an applied request waiting for the pod to complete its motion, or for a number of ticks
+type waiter struct {
+	motion bool
+	ticks  int
+	done   chan error
+}
+
+type Engine struct {
+	Shell *Shell
+	// Commands sent from other goroutines (see Submit)
+	requests chan request
+	// Command at the head of the queue waiting for the pod (see ErrPodBusy)
+	waiting *request
+	// Applied requests waiting for the pod or the clock (see SendAndWait and Wait)
+	pending []*waiter
+	// Scripts run as fast as possible on the goroutine running the simulation loop (see RunScript)
+	synchronous bool
+	// A script is running on a separate goroutine (see the run command)
+	scriptRunning atomic.Bool
+	// Pod the engine receives events from. Some commands (reset) replace the pod
+	listening *robot.Pod
+	// Channels receiving the events of the pod (see Subscribe)
+	subscribers      map[chan robot.PodEvent]bool
+	subscribersMutex sync.Mutex
+}
+
+// NewEngine creates an engine for a pod. The output of the shell is sent to the output channel,
+// which must be read by the caller
+func NewEngine(pod *robot.Pod, output chan string) *Engine {
+	e := &Engine{Shell: NewShell(pod, output),
+		requests:    make(chan request, COMMAND_QUEUE_SIZE),
+		subscribers: map[chan robot.PodEvent]bool{},
+	}
+	e.Shell.dispatchMap["run"] = e.executeRunCmd
+	e.listen()
+	return e
+}
+
+// Subscribe returns a channel receiving the events of the pod (see robot.PodEvent), and a function ending
+// the subscription. Subscribe is safe to call from any goroutine. Events are dropped if the channel is full
+func (e *Engine) Subscribe() (<-chan robot.PodEvent, func()) {
+	events := make(chan robot.PodEvent, EVENT_QUEUE_SIZE)
+	e.subscribersMutex.Lock()
+	e.subscribers[events] = true
+	e.subscribersMutex.Unlock()
+	return events, func() {
+		e.subscribersMutex.Lock()
+		delete(e.subscribers, events)
+		e.subscribersMutex.Unlock()
+	}
+}
+
+// listen receives the events of the pod, unless the engine already does
+func (e *Engine) listen() {
+	if e.listening == e.Pod() {
+		return
+	}
+	e.listening = e.Pod()
+	e.listening.AddEventListener(e.publish)
+}
+
+// publish sends an event of the pod to the subscribers (see Subscribe)
+func (e *Engine) publish(event robot.PodEvent) {
+	if e.Shell.ShowEvents {
+		e.Shell.outputCh <- event.String()
+	}
+	e.subscribersMutex.Lock()
+	defer e.subscribersMutex.Unlock()
+	for events := range e.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// Submit queues a shell command for the simulation loop, and returns a channel that receives the
+// result once the command has been applied (see ApplyCommands). Submit is safe to call from any goroutine
+func (e *Engine) Submit(command string) <-chan error {
+	done := make(chan error, 1)
+	e.requests <- request{command: command, done: done}
+	return done
+}
+
+// Send queues a shell command for the simulation loop and waits for the result. Send must not be
+// called from the goroutine running the simulation loop
+func (e *Engine) Send(command string) error {
+	return <-e.Submit(command)
+}
+
+// SendAndWait queues a shell command for the simulation loop, and waits until the pod has completed the
+// motion started by the command (see motionComplete). SendAndWait must not be called from the goroutine
+// running the simulation loop
+func (e *Engine) SendAndWait(command string) error {
+	done := make(chan error, 1)
+	e.requests <- request{command: command, motion: true, done: done}
+	return <-done
+}
+
+// Wait waits until the simulation loop has moved the pod through a duration of simulated time. Wait must
+// not be called from the goroutine running the simulation loop
+func (e *Engine) Wait(d time.Duration) error {
+	done := make(chan error, 1)
+	e.requests <- request{duration: d, done: done}
+	return <-done
+}
+
+// ApplyCommands applies the queued commands in order. It is called by the simulation loop between ticks
+func (e *Engine) ApplyCommands() {
+	defer e.completeWaiters()
+	for {
+		if e.waiting == nil {
+			select {
+			case r := <-e.requests:
+				e.waiting = &r
+			default:
+				return
+			}
+		}
+		var err error
+		if e.waiting.command != "" {
+			err = e.Execute(e.waiting.command)
+		}
+		if errors.Is(err, ErrPodBusy) {
+			return
+		}
+		if err != nil || (!e.waiting.motion && e.waiting.duration <= 0) {
+			e.waiting.done <- err
+		} else {
+			ticks := int(math.Round(e.waiting.duration.Seconds() * e.Pod().Clock.Rate))
+			e.pending = append(e.pending, &waiter{motion: e.waiting.motion, ticks: ticks, done: e.waiting.done})
+		}
+		e.waiting = nil
+	}
+}
+
+// motionComplete returns true once the pod has completed its motion. A pod walking until it is stopped
+// has no motion to complete (see Pod.WalksUntilStopped)
+func (e *Engine) motionComplete() bool {
+	p := e.Pod()
+	return !p.IsMoving() || (p.WalksUntilStopped() && !p.IsPosing())
+}
+
+// completeWaiters signals the requests that no longer wait for the pod or the clock
+func (e *Engine) completeWaiters() {
+	pending := e.pending[:0]
+	for _, w := range e.pending {
+		if w.ticks <= 0 && (!w.motion || e.motionComplete()) {
+			w.done <- nil
+			continue
+		}
+		pending = append(pending, w)
+	}
+	e.pending = pending
+}
+
+// Pod returns the pod of the engine. Some commands (reset) replace the pod
+func (e *Engine) Pod() *robot.Pod {
+	return e.Shell.Pod
+}
+
+// Execute runs a shell command (see help) right away. Execute must only be called from the goroutine
+// running the simulation loop. Other goroutines use Submit or Send
+func (e *Engine) Execute(command string) error {
+	defer e.listen()
+	return e.Shell.Dispatch(command)
+}
+
+// update moves the pod one tick and streams the servo angles
+func (e *Engine) update() {
+	e.Shell.Pod.Update()
+	e.Shell.Network.Update()
+	for _, w := range e.pending {
+		w.ticks--
+	}
+	e.completeWaiters()
+}
+
+// Step moves the pod a number of ticks as fast as possible
+func (e *Engine) Step(ticks int) {
+	for i := 0; i < ticks; i++ {
+		e.Pod().Clock.Tick()
+		e.update()
+	}
+}
+
+// RunFor moves the pod through a duration of simulated time as fast as possible, and returns the number of ticks
+func (e *Engine) RunFor(d time.Duration) int {
+	ticks := int(math.Round(d.Seconds() * e.Pod().Clock.Rate))
+	e.Step(ticks)
+	return ticks
+}
+
+// RunUntilIdle moves the pod as fast as possible until it stops moving (see Pod.IsMoving), and returns
+// the number of ticks. An error is returned if the pod is still moving after timeout (simulated time)
+func (e *Engine) RunUntilIdle(timeout time.Duration) (int, error) {
+	return e.runUntil(timeout, func() bool { return !e.Pod().IsMoving() })
+}
+
+// runUntil moves the pod as fast as possible until done returns true, and returns the number of ticks.
+// An error is returned if done is still false after timeout (simulated time)
+func (e *Engine) runUntil(timeout time.Duration, done func() bool) (int, error) {
+	maxTicks := int(math.Round(timeout.Seconds() * e.Pod().Clock.Rate))
+	ticks := 0
+	for ; !done(); ticks++ {
+		if ticks >= maxTicks {
+			return ticks, fmt.Errorf("the pod is still moving after %2.2f s", timeout.Seconds())
+		}
+		e.Step(1)
+	}
+	return ticks, nil
+}
+
+// Advance moves the pod by the real time elapsed since the previous call, scaled by the
+// simulation speed (see the speed command), and returns the number of ticks
+func (e *Engine) Advance(elapsed time.Duration) int {
+	ticks := e.Pod().Clock.Advance(time.Duration(float64(elapsed) * e.Shell.Speed))
+	for i := 0; i < ticks; i++ {
+		e.update()
+	}
+	return ticks
+}
+
+// RunHeadless runs shell commands read from in (one per line) without a display, and writes the output to out.
+// With realTime false, the pod completes the motion of every command (see motionComplete) as fast as possible
+// before the next command runs. A pod walking until it is stopped (nrepeats 0) has no motion to complete, so a
+// script (see the run command) decides how long it walks with wait. With realTime true, the commands run as
+// they arrive while the pod moves in real time, for streaming to a robot. RunHeadless returns at the end of the
+// input, once the pod has stopped moving and any script has completed
+func (e *Engine) RunHeadless(in io.Reader, out io.Writer, realTime bool) error {
+	var printing sync.WaitGroup
+	printing.Add(1)
+	go func() {
+		defer printing.Done()
+		for line := range e.Shell.Output() {
+			fmt.Fprintln(out, line)
+		}
+	}()
+	defer func() {
+		close(e.Shell.Output())
+		printing.Wait()
+	}()
+
+	scanner := bufio.NewScanner(in)
+	if !realTime {
+		e.synchronous = true
+		defer func() { e.synchronous = false }()
+		for scanner.Scan() {
+			e.runCommand(scanner.Text())
+			if _, err := e.runUntil(HEADLESS_TIMEOUT, e.motionComplete); err != nil {
+				e.Shell.outputCh <- err.Error()
+			}
+		}
+		return scanner.Err()
+	}
+
+	// The commands are read and sent to the simulation loop by a separate goroutine
+	inputDone := make(chan struct{})
+	go func() {
+		defer close(inputDone)
+		for scanner.Scan() {
+			command := scanner.Text()
+			if strings.TrimSpace(command) == "" {
+				continue
+			}
+			if err := e.Send(command); err != nil {
+				e.Shell.outputCh <- err.Error()
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(REAL_TIME_INTERVAL)
+	defer ticker.Stop()
+	last := time.Now()
+	for inputDone != nil || e.Pod().IsMoving() || e.scriptRunning.Load() {
+		select {
+		case <-inputDone:
+			inputDone = nil
+		case now := <-ticker.C:
+			e.ApplyCommands()
+			e.Advance(now.Sub(last))
+			last = now
+		}
+	}
+	return scanner.Err()
+}
+
+// runCommand applies a shell command in the simulation loop, moving the pod until the command has been applied
+// (see ErrPodBusy). Any error is sent to the output
+func (e *Engine) runCommand(command string) {
+	if strings.TrimSpace(command) == "" {
+		return
+	}
+	done := e.Submit(command)
+	for {
+		e.ApplyCommands()
+		select {
+		case err := <-done:
+			if err != nil {
+				e.Shell.outputCh <- err.Error()
+			}
+			return
+		default:
+			e.Step(1)
+		}
+	}
+}
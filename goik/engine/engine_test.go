@@ -0,0 +1,178 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"GOIK/robot"
+)
+
+const testTimeout = 10 * time.Second
+
+// newTestEngine creates an engine for the example hexapod 0, and discards the output of the shell
+func newTestEngine(t *testing.T) *Engine {
+	t.Helper()
+	output := make(chan string, 100)
+	done := make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-output:
+			case <-done:
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() { close(done) })
+	return NewEngine(robot.NewPod(robot.NewExampleHexapod0()), output)
+}
+
+// execute runs shell commands on the engine, and fails the test at the first error
+func execute(t *testing.T, e *Engine, commands ...string) {
+	t.Helper()
+	for _, command := range commands {
+		if err := e.Execute(command); err != nil {
+			t.Fatalf("%s: %v", command, err)
+		}
+	}
+}
+
+func TestWalkStrideVector(t *testing.T) {
+	e := newTestEngine(t)
+	execute(t, e, "stride_vector 2 20 0", "start")
+
+	ticks, err := e.RunUntilIdle(testTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticks == 0 {
+		t.Fatal("the pod did not move")
+	}
+
+	p := e.Pod()
+	if p.State() != robot.StrideDefined {
+		t.Errorf("state is %s, want %s", p.State(), robot.StrideDefined)
+	}
+	if p.GetCurrentGaitCycle() != 2 {
+		t.Errorf("completed %d gait cycles, want 2", p.GetCurrentGaitCycle())
+	}
+
+	// Every leg ends on the ground at one end of its stride
+	for _, l := range p.Legs {
+		effector, neutral := l.Effector(), l.NeutralEffectorCoordinate
+		if math.Abs(math.Abs(effector.X-neutral.X)-20) > 1e-6 ||
+			math.Abs(effector.Y-neutral.Y) > 1e-6 || math.Abs(effector.Z-neutral.Z) > 1e-6 {
+			t.Errorf("leg %d: effector at %+v, neutral at %+v", l.Index, effector, neutral)
+		}
+	}
+}
+
+func TestStepCompletesOneCycle(t *testing.T) {
+	e := newTestEngine(t)
+	execute(t, e, "stride_vector 1 20 0", "start")
+	cycleTicks, err := e.RunUntilIdle(testTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	execute(t, e, "step")
+	ticks, err := e.RunUntilIdle(testTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticks != cycleTicks {
+		t.Errorf("step took %d ticks, want %d", ticks, cycleTicks)
+	}
+	if e.Pod().GetCurrentGaitCycle() != 2 {
+		t.Errorf("completed %d gait cycles, want 2", e.Pod().GetCurrentGaitCycle())
+	}
+}
+
+// Commands returning ErrPodBusy stay at the head of the queue, and the commands behind them wait
+func TestBusyCommandsApplyInOrder(t *testing.T) {
+	e := newTestEngine(t)
+	// Walk away from the neutral stance, so that revert has something to do
+	execute(t, e, "stride_vector 1 20 0", "start")
+	if _, err := e.RunUntilIdle(testTimeout); err != nil {
+		t.Fatal(err)
+	}
+	execute(t, e, "pitch 5")
+	if !e.Pod().IsPosing() {
+		t.Fatal("the pod is not posing")
+	}
+
+	commands := []string{"revert", "gait ripple", "stride_vector 1 0 20"}
+	results := make([]<-chan error, len(commands))
+	for i, command := range commands {
+		results[i] = e.Submit(command)
+	}
+
+	applied := make([]int, len(commands))
+	for i := range applied {
+		applied[i] = -1
+	}
+	maxTicks := int(testTimeout.Seconds() * e.Pod().Clock.Rate)
+	for tick := 0; tick < maxTicks && applied[len(applied)-1] < 0; tick++ {
+		e.ApplyCommands()
+		for i, result := range results {
+			select {
+			case err := <-result:
+				if err != nil {
+					t.Fatalf("%s: %v", commands[i], err)
+				}
+				applied[i] = tick
+				p := e.Pod()
+				switch commands[i] {
+				case "revert":
+					if p.IsPosing() || !p.IsReverting() {
+						t.Errorf("revert applied in state %s", p.State())
+					}
+				case "gait ripple":
+					if p.IsReverting() || p.NextGait().Name != "Ripple gait" {
+						t.Errorf("gait applied in state %s (%s)", p.State(), p.NextGait().Name)
+					}
+				}
+			default:
+			}
+		}
+		e.Step(1)
+	}
+
+	for i, command := range commands {
+		if applied[i] < 0 {
+			t.Fatalf("%s was not applied", command)
+		}
+	}
+	if applied[0] == 0 {
+		t.Error("revert was applied while the pod was posing")
+	}
+	if applied[1] <= applied[0] {
+		t.Errorf("gait applied at tick %d, before the pod reverted (revert applied at tick %d)", applied[1], applied[0])
+	}
+	if applied[2] < applied[1] {
+		t.Errorf("stride_vector applied at tick %d, before the gait (tick %d)", applied[2], applied[1])
+	}
+}
+
+func TestResetKeepsTickRate(t *testing.T) {
+	e := newTestEngine(t)
+	execute(t, e, "rate 100", "reset 1")
+	if e.Pod().Clock.Rate != 100 || e.Pod().NextGait().TickRate != 100 {
+		t.Errorf("clock at %2.2f Hz and gait at %2.2f Hz after reset, want 100 Hz", e.Pod().Clock.Rate, e.Pod().NextGait().TickRate)
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+	Notes regarding scripts
+
+	1) A script is a text file in the scripts folder with one shell command per line (see help).
+	   Everything after a '#' is a comment, and empty lines are ignored.
+	2) Besides the shell commands, a script may use:
+		- wait <ms>:         Wait a number of milliseconds of simulated time while the pod keeps moving.
+		- repeat <n> { ... }: Run the lines up to the matching '}' n times. Repeats may be nested.
+	3) Every command runs once the previous command has completed its motion. The pod completes
+	   the gait cycles of start and step, reaches the neutral stance after revert and reaches a new body
+	   pose before the next line runs. A pod walking until it is stopped (nrepeats 0) does not block the script,
+	   so the script decides how long it walks (wait) before it stops.
+	4) The script is parsed before it runs, and it stops at the first failing command. Since the script
+	   waits for the pod rather than for the wall clock, a script replays the same motion (and recording)
+	   every time, at any simulation speed. In real time, a command is applied between two updates of the
+	   loop, so a command following a wait (stop after walking etc) may be applied a few ticks later.
+	5) The simulator and the real time headless mode run a script on a separate goroutine, sending the commands
+	   to the simulation loop (see SendAndWait and Wait). The headless mode without real time runs the
+	   script on the simulation loop, moving the pod as fast as possible (see RunScript).
+*/
+
+const SCRIPTS_FOLDER = "scripts"
+
+// scriptStep is a line of a script: a shell command, a wait or a repeated block of steps
+type scriptStep struct {
+	// Line number (from 1) in the script file
+	line int
+	// Shell command (empty for wait and repeat)
+	command string
+	// Simulated time to wait (wait)
+	wait time.Duration
+	// Number of times the body runs (repeat)
+	repeats int
+	body    []scriptStep
+}
+
+// scriptExecutor applies the commands of a script to the engine
+type scriptExecutor interface {
+	// execute applies a shell command and waits until the pod has completed the motion started by the command
+	execute(command string) error
+	// wait waits a duration of simulated time
+	wait(d time.Duration) error
+}
+
+// loopExecutor applies the commands on the goroutine running the simulation loop, and moves the pod as fast as possible
+type loopExecutor struct {
+	e *Engine
+}
+
+func (x loopExecutor) execute(command string) error {
+	for {
+		err := x.e.Execute(command)
+		if !errors.Is(err, ErrPodBusy) {
+			if err != nil {
+				return err
+			}
+			break
+		}
+		x.e.Step(1)
+	}
+	_, err := x.e.runUntil(HEADLESS_TIMEOUT, x.e.motionComplete)
+	return err
+}
+
+func (x loopExecutor) wait(d time.Duration) error {
+	x.e.RunFor(d)
+	return nil
+}
+
+// sendExecutor sends the commands to the simulation loop from another goroutine
+type sendExecutor struct {
+	e *Engine
+}
+
+func (x sendExecutor) execute(command string) error {
+	return x.e.SendAndWait(command)
+}
+
+func (x sendExecutor) wait(d time.Duration) error {
+	return x.e.Wait(d)
+}
+
+// loadScript reads and parses a script from the scripts folder
+func loadScript(name string) ([]scriptStep, error) {
+	data, err := os.ReadFile(fmt.Sprintf("./%s/%s", SCRIPTS_FOLDER, name))
+	if err != nil {
+		return nil, err
+	}
+	steps, _, err := parseScript(strings.Split(string(data), "\n"), 0, false)
+	if err != nil {
+		return nil, fmt.Errorf("script %s: %w", name, err)
+	}
+	return steps, nil
+}
+
+// parseScript parses the lines of a script from start, and returns the steps and the index of the last line parsed.
+// A nested block (the body of a repeat) ends at the matching '}'
+func parseScript(lines []string, start int, nested bool) ([]scriptStep, int, error) {
+	steps := []scriptStep{}
+	for i := start; i < len(lines); i++ {
+		line, _, _ := strings.Cut(lines[i], "#")
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+
+		switch args[0] {
+		case "}":
+			if !nested || len(args) != 1 {
+				return nil, i, fmt.Errorf("line %d: unexpected '}'", i+1)
+			}
+			return steps, i, nil
+
+		case "repeat":
+			if len(args) != 3 || args[2] != "{" {
+				return nil, i, fmt.Errorf("line %d: syntax error ('repeat <n> {'): %+v", i+1, args)
+			}
+			repeats, err := strconv.Atoi(args[1])
+			if err != nil || repeats < 1 {
+				return nil, i, fmt.Errorf("line %d: the number of repeats must be a positive integer: %+v", i+1, args)
+			}
+			body, end, err := parseScript(lines, i+1, true)
+			if err != nil {
+				return nil, end, err
+			}
+			if end >= len(lines) {
+				return nil, end, fmt.Errorf("line %d: missing '}' after 'repeat'", i+1)
+			}
+			steps = append(steps, scriptStep{line: i + 1, repeats: repeats, body: body})
+			i = end
+
+		case "wait":
+			if len(args) != 2 {
+				return nil, i, fmt.Errorf("line %d: syntax error ('wait <ms>'): %+v", i+1, args)
+			}
+			ms, err := strconv.ParseFloat(args[1], 64)
+			if err != nil || ms < 0 {
+				return nil, i, fmt.Errorf("line %d: syntax error ('wait <ms>'): %+v", i+1, args)
+			}
+			steps = append(steps, scriptStep{line: i + 1, wait: time.Duration(ms * float64(time.Millisecond))})
+
+		case "run":
+			return nil, i, fmt.Errorf("line %d: a script can not run another script", i+1)
+
+		default:
+			steps = append(steps, scriptStep{line: i + 1, command: strings.Join(args, " ")})
+		}
+	}
+	// A nested block without '}' is reported by the caller (see repeat)
+	return steps, len(lines), nil
+}
+
+// runScript runs the steps of a script in order, and stops at the first error. Errors are reported with the
+// line number, and the iterations of the repeats the line is in (outermost first, e.g. "line 7 (repeat 2/4, 1/3)")
+func (e *Engine) runScript(steps []scriptStep, x scriptExecutor, iterations []string) error {
+	for _, step := range steps {
+		var err error
+		switch {
+		case step.body != nil:
+			for i := 0; i < step.repeats && err == nil; i++ {
+				iteration := fmt.Sprintf("%d/%d", i+1, step.repeats)
+				err = e.runScript(step.body, x, append(iterations[:len(iterations):len(iterations)], iteration))
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		case step.command != "":
+			err = x.execute(step.command)
+		default:
+			e.Shell.outputCh <- fmt.Sprintf("[wait %d]", step.wait.Milliseconds())
+			err = x.wait(step.wait)
+		}
+		if err != nil {
+			if len(iterations) > 0 {
+				return fmt.Errorf("line %d (repeat %s): %w", step.line, strings.Join(iterations, ", "), err)
+			}
+			return fmt.Errorf("line %d: %w", step.line, err)
+		}
+	}
+	return nil
+}
+
+// RunScript runs a script from the scripts folder (see the notes above), moving the pod as fast as possible.
+// RunScript must only be called from the goroutine running the simulation loop, and returns once the script has completed
+func (e *Engine) RunScript(name string) error {
+	steps, err := loadScript(name)
+	if err != nil {
+		return err
+	}
+	err = e.runScript(steps, loopExecutor{e}, nil)
+	if err != nil {
+		return fmt.Errorf("script %s: %w", name, err)
+	}
+	return nil
+}
+
+func (e *Engine) executeRunCmd(args []string) error {
+	e.Shell.outputCh <- fmt.Sprintf("%+v", args)
+
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error ('run <script>'): %+v", args)
+	}
+
+	if e.synchronous {
+		err := e.RunScript(args[1])
+		if err != nil {
+			return err
+		}
+		e.Shell.outputCh <- fmt.Sprintf("Script %s complete", args[1])
+		return nil
+	}
+
+	steps, err := loadScript(args[1])
+	if err != nil {
+		return err
+	}
+	if !e.scriptRunning.CompareAndSwap(false, true) {
+		return fmt.Errorf("another script is already running")
+	}
+	go func() {
+		defer e.scriptRunning.Store(false)
+		err := e.runScript(steps, sendExecutor{e}, nil)
+		if err != nil {
+			e.Shell.outputCh <- fmt.Sprintf("script %s: %s", args[1], err.Error())
+			return
+		}
+		e.Shell.outputCh <- fmt.Sprintf("Script %s complete", args[1])
+	}()
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeScripts writes scripts to the scripts folder of a temporary working directory
+func writeScripts(t *testing.T, scripts map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, SCRIPTS_FOLDER), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, SCRIPTS_FOLDER, name), []byte(script), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestScriptRepeatAndWait(t *testing.T) {
+	writeScripts(t, map[string]string{"walk": `
+stride_vector 1 20 0
+repeat 2 {
+	step
+}
+wait 200
+`})
+
+	// The same motion, one command at a time
+	expected := newTestEngine(t)
+	execute(t, expected, "stride_vector 1 20 0")
+	for i := 0; i < 2; i++ {
+		execute(t, expected, "step")
+		if _, err := expected.RunUntilIdle(testTimeout); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected.RunFor(200 * time.Millisecond)
+
+	e := newTestEngine(t)
+	if err := e.RunScript("walk"); err != nil {
+		t.Fatal(err)
+	}
+	p := e.Pod()
+	if p.GetCurrentGaitCycle() != expected.Pod().GetCurrentGaitCycle() {
+		t.Errorf("completed %d gait cycles, want %d", p.GetCurrentGaitCycle(), expected.Pod().GetCurrentGaitCycle())
+	}
+	if p.Clock.Ticks != expected.Pod().Clock.Ticks {
+		t.Errorf("the script took %d ticks, want %d", p.Clock.Ticks, expected.Pod().Clock.Ticks)
+	}
+	if p.IsMoving() {
+		t.Errorf("the pod is still moving (%s)", p.State())
+	}
+}
+
+func TestScriptErrors(t *testing.T) {
+	writeScripts(t, map[string]string{
+		"unknown": "stride_vector 1 20 0\nrepeat 2 {\n\trepeat 3 {\n\t\tstep\n\t}\n\tfoo\n}\n",
+		"brace":   "repeat 2 {\n\tstep\n",
+		"nested":  "run other\n",
+	})
+
+	tests := []struct {
+		script string
+		err    string
+	}{
+		{"unknown", "script unknown: line 6 (repeat 1/2): "},
+		{"brace", "script brace: line 1: missing '}'"},
+		{"nested", "script nested: line 1: a script can not run another script"},
+		{"missing", "no such file"},
+	}
+	for _, test := range tests {
+		e := newTestEngine(t)
+		err := e.RunScript(test.script)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want %q", test.script, err, test.err)
+		}
+	}
+}
@@ -12,27 +12,37 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package simulator
+package engine
 
 import (
+	"GOIK/comms"
 	"GOIK/robot"
-	"log"
-	"strings"
-
-	"github.com/borud/chatui"
+	"errors"
 )
 
 type dispatchFunc func(args []string) error
 
+// ErrPodBusy is returned by commands that have to wait for the pod to complete its current motion.
+// The engine applies the command again after the next tick (see Engine.ApplyCommands)
+var ErrPodBusy = errors.New("the pod is busy completing its current motion")
+
+// Shell dispatches text commands to the pod. All output (command echo, results and pod debug messages)
+// is sent to the output channel, which must be read by the caller
 type Shell struct {
-	Pod         *robot.Pod
+	Pod *robot.Pod
+	// Streams the servo angles to the robot
+	Network *comms.NetworkController
+	// Simulated time per real time (see the speed command)
+	Speed float64
+	// Output the events of the pod (see the events command)
+	ShowEvents  bool
 	outputCh    chan string
-	commandCh   chan string
 	dispatchMap map[string]dispatchFunc
 }
 
-func NewShell(pod *robot.Pod) *Shell {
-	s := Shell{Pod: pod, outputCh: make(chan string, 10), commandCh: make(chan string)}
+func NewShell(pod *robot.Pod, outputCh chan string) *Shell {
+	s := Shell{Pod: pod, Speed: 1, outputCh: outputCh}
+	s.Network = comms.NewNetworkController(1, pod, outputCh)
 
 	s.Pod.SetDebugChannel(s.outputCh)
 
@@ -47,12 +57,19 @@ func NewShell(pod *robot.Pod) *Shell {
 		"set_tibia_angle":  s.executeSetTibiaAngleCmd,
 		"stride_vector":    s.executeStrideVectorCmd,
 		"stride_angle":     s.executeStrideAngleCmd,
+		"stride_motion":    s.executeStrideMotionCmd,
+		"velocity":         s.executeVelocityCmd,
+		"auto_gait":        s.executeAutoGaitCmd,
 		"start":            s.executeStartCmd,
 		"stop":             s.executeStopCmd,
 		"reset":            s.executeResetCmd,
 		"speed":            s.executeSpeedCmd,
+		"rate":             s.executeRateCmd,
 		"zlift":            s.executeZLiftCmd,
 		"gait":             s.executeGaitCmd,
+		"duty":             s.executeDutyCmd,
+		"phase":            s.executePhaseCmd,
+		"swing":            s.executeSwingCmd,
 		"open":             s.executeOpenServoPortCmd,
 		"close":            s.executeCloseServoPortCmd,
 		"save":             s.executeSaveCmd,
@@ -61,61 +78,35 @@ func NewShell(pod *robot.Pod) *Shell {
 		"reverse":          s.executeReverseCmd,
 		"revert":           s.executeRevertCmd,
 		"record":           s.executeRecordCmd,
+		"interpolation":    s.executeInterpolationCmd,
 		"export":           s.executeExportCmd,
 		"debug":            s.executeDebugCmd,
 		"step":             s.executeStepCycleCmd,
+		"state":            s.executeStateCmd,
+		"events":           s.executeEventsCmd,
 		"pitch":            s.executePitchCmd,
 		"yaw":              s.executeYawCmd,
 		"roll":             s.executeRollCmd,
 		"up":               s.executeUpCmd,
 		"down":             s.executeDownCmd,
+		"shift":            s.executeShiftCmd,
+		"pose":             s.executePoseCmd,
 		"ground":           s.executeGroundCmd,
+		"limits":           s.executeLimitsCmd,
+		"set_limit":        s.executeSetLimitCmd,
+		"knee":             s.executeKneeCmd,
+		"workspace":        s.executeWorkspaceCmd,
+		"max_stride":       s.executeMaxStrideCmd,
+		"stability":        s.executeStabilityCmd,
+		"mass":             s.executeMassCmd,
+		"payload":          s.executePayloadCmd,
+		"torque":           s.executeTorqueCmd,
 	}
 
 	return &s
 }
 
-func (s *Shell) Run() {
-
-	chatui := chatui.New(chatui.Config{
-		OutputCh:     s.outputCh,
-		CommandCh:    s.commandCh,
-		DynamicColor: false,
-		BlockCtrlC:   true,
-		HistorySize:  10,
-	})
-
-	s.outputCh <- "Pod playground"
-
-	go func() {
-		for command := range s.commandCh {
-			if strings.ToLower(command) == "/quit" {
-				chatui.Stop()
-			}
-			err := s.Dispatch(command)
-			if err != nil {
-				s.outputCh <- err.Error()
-			}
-			chatui.SetStatus("last command was: " + command)
-		}
-	}()
-
-	go func() {
-		// this is done in a goroutine because it will block if the UI is not running.
-		chatui.SetStatus("type /quit to exit")
-
-		s.outputCh <- "-----------------------------------"
-		s.outputCh <- "Hexapod Designer"
-		s.outputCh <- "Copyright Hans Jørgen Grimstad 2024"
-		s.outputCh <- "www.TimeExpander.com"
-		s.outputCh <- "-----------------------------------"
-		s.outputCh <- ""
-		s.outputCh <- "Type 'help' for a list of commands"
-
-	}()
-
-	err := chatui.Run()
-	if err != nil {
-		log.Fatal(err)
-	}
+// Output returns the channel receiving the output of the shell
+func (s *Shell) Output() chan string {
+	return s.outputCh
 }
@@ -0,0 +1,87 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import "fmt"
+
+// Default switching speeds (mm/s) for automatic gait selection
+const (
+	AUTO_GAIT_RIPPLE_SPEED = 25.0
+	AUTO_GAIT_TRIPOD_SPEED = 60.0
+	AUTO_GAIT_HYSTERESIS   = 5.0
+)
+
+// Gaits used by automatic gait selection, from the slowest to the fastest
+var autoGaitTypes = []GaitType{WAVE, RIPPLE, TRIPOD}
+
+// AutoGait selects the gait from the walking speed, the way insects do. Low speeds use the wave gait
+// (most legs on the ground), medium speeds use the ripple gait and high speeds use the tripod gait.
+type AutoGait struct {
+	// Speeds (mm/s) above which the pod switches from wave to ripple gait, and from ripple to tripod gait
+	RippleSpeed float64
+	TripodSpeed float64
+	// The pod switches back to the slower gait when the speed drops this far (mm/s) below
+	// the switching speed. This keeps the pod from switching back and forth around a switching speed
+	Hysteresis float64
+}
+
+// NewAutoGait returns an automatic gait selection with the default switching speeds
+func NewAutoGait() *AutoGait {
+	return &AutoGait{RippleSpeed: AUTO_GAIT_RIPPLE_SPEED, TripodSpeed: AUTO_GAIT_TRIPOD_SPEED, Hysteresis: AUTO_GAIT_HYSTERESIS}
+}
+
+// Validate returns an error if the switching speeds are unusable
+func (a *AutoGait) Validate() error {
+	if a.RippleSpeed <= 0 || a.TripodSpeed <= a.RippleSpeed {
+		return fmt.Errorf("the switching speeds must satisfy 0 < ripple speed (%2.2f) < tripod speed (%2.2f)", a.RippleSpeed, a.TripodSpeed)
+	}
+	if a.Hysteresis < 0 || a.Hysteresis >= a.RippleSpeed {
+		return fmt.Errorf("the hysteresis must be between 0 and the ripple speed (was %2.2f)", a.Hysteresis)
+	}
+	return nil
+}
+
+// Select returns the gait type for a speed (mm/s), given the gait type selected for the previous speed
+func (a *AutoGait) Select(current GaitType, speed float64) GaitType {
+	thresholds := []float64{a.RippleSpeed, a.TripodSpeed}
+
+	level := 0
+	for i, t := range autoGaitTypes {
+		if t == current {
+			level = i
+		}
+	}
+	for level < len(thresholds) && speed > thresholds[level] {
+		level++
+	}
+	for level > 0 && speed < thresholds[level-1]-a.Hysteresis {
+		level--
+	}
+	return autoGaitTypes[level]
+}
+
+// SetAutoGait enables automatic gait selection for SetVelocity. nil disables automatic gait selection
+func (p *Pod) SetAutoGait(a *AutoGait) error {
+	if a != nil {
+		err := a.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	p.AutoGait = a
+	p.autoGaitType = autoGaitTypes[0]
+	p.autoGaitSelected = false
+	return nil
+}
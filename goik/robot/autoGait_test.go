@@ -0,0 +1,74 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import "testing"
+
+func TestAutoGaitSelect(t *testing.T) {
+	a := &AutoGait{RippleSpeed: 20, TripodSpeed: 50, Hysteresis: 5}
+	tests := []struct {
+		current GaitType
+		speed   float64
+		want    GaitType
+	}{
+		{WAVE, 10, WAVE},
+		{WAVE, 25, RIPPLE},
+		{WAVE, 60, TRIPOD},
+		{RIPPLE, 18, RIPPLE},
+		{RIPPLE, 14, WAVE},
+		{RIPPLE, 52, TRIPOD},
+		{TRIPOD, 47, TRIPOD},
+		{TRIPOD, 44, RIPPLE},
+		{TRIPOD, 0, WAVE},
+		// Gaits not used by the selection start from the slowest gait
+		{METACHRONAL, 30, RIPPLE},
+	}
+
+	for _, test := range tests {
+		if got := a.Select(test.current, test.speed); got != test.want {
+			t.Errorf("%s at %2.2f mm/s: %s, want %s", test.current, test.speed, got, test.want)
+		}
+	}
+}
+
+func TestValidateAutoGait(t *testing.T) {
+	tests := []struct {
+		name  string
+		auto  AutoGait
+		valid bool
+	}{
+		{"default", *NewAutoGait(), true},
+		{"no hysteresis", AutoGait{RippleSpeed: 20, TripodSpeed: 50}, true},
+		{"zero ripple speed", AutoGait{RippleSpeed: 0, TripodSpeed: 50}, false},
+		{"tripod slower than ripple", AutoGait{RippleSpeed: 50, TripodSpeed: 20}, false},
+		{"negative hysteresis", AutoGait{RippleSpeed: 20, TripodSpeed: 50, Hysteresis: -1}, false},
+		{"hysteresis above the ripple speed", AutoGait{RippleSpeed: 20, TripodSpeed: 50, Hysteresis: 20}, false},
+	}
+
+	p := NewPod(NewExampleHexapod1())
+	for _, test := range tests {
+		err := p.SetAutoGait(&test.auto)
+		if test.valid && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+
+	if err := p.SetAutoGait(nil); err != nil || p.AutoGait != nil {
+		t.Error("automatic gait selection is still enabled")
+	}
+}
@@ -39,6 +39,88 @@ type BodyDefinition struct {
 	Segments []SegmentLengths `json:"Segments"`
 	// The angles (in degrees) for a robot in a neutral/rest stance
 	RestAngles []ServoAngles `json:"Angles"`
+	// Optional range of motion (and speed) for each joint in each leg.
+	// Legs without an entry are not limited.
+	JointLimits []JointLimits `json:"JointLimits,omitempty"`
+	// Optional kinematic chain (Denavit-Hartenberg links) for each leg. This allows for
+	// legs with any number of joints (2-DOF legs, 4-DOF legs with a tarsus joint etc).
+	// Legs without an entry are standard coxa, femur and tibia legs defined by Segments.
+	Chains []DHChain `json:"Chains,omitempty"`
+	// Optional knee configuration for each leg. Legs without an entry are knee up.
+	Knees []KneeConfiguration `json:"Knees,omitempty"`
+	// Optional mass properties. Without these the centre of mass is assumed to be
+	// the origin of the body reference frame.
+	Masses *MassProperties `json:"Masses,omitempty"`
+}
+
+// LegMasses contains the masses (in grams) of the parts of a leg
+type LegMasses struct {
+	// Mass of each segment (link) ordered along the kinematic chain (coxa, femur, tibia, ...)
+	// The mass of a segment is located halfway between the two joints it connects
+	Segments []float64 `json:"Segments,omitempty"`
+	// Mass of each servo ordered along the kinematic chain. The mass of a servo is located
+	// at the origin of the joint it drives
+	Servos []float64 `json:"Servos,omitempty"`
+}
+
+// MassProperties contains the masses (in grams) of the parts of a pod
+type MassProperties struct {
+	// Mass of the body (including electronics etc). The mass is located at the
+	// origin of the body reference frame
+	Body float64 `json:"Body"`
+	// Masses for each leg. Legs without an entry are massless
+	Legs []LegMasses `json:"Legs,omitempty"`
+	// Mass of any payload (battery, sensors etc) carried by the pod
+	Payload float64 `json:"Payload,omitempty"`
+	// Location of the payload's centre of mass in the body reference frame
+	PayloadPosition Coordinate `json:"PayloadPosition"`
+}
+
+// GetLegMasses returns the masses for a given leg
+func (b *BodyDefinition) GetLegMasses(legNum int) LegMasses {
+	if b.Masses != nil && legNum < len(b.Masses.Legs) {
+		return b.Masses.Legs[legNum]
+	}
+	return LegMasses{}
+}
+
+// HasChain returns true if a given leg is defined by its own kinematic chain (see Chains)
+func (b *BodyDefinition) HasChain(legNum int) bool {
+	return legNum < len(b.Chains) && len(b.Chains[legNum]) > 0
+}
+
+// GetChain returns the kinematic chain for a given leg
+func (b *BodyDefinition) GetChain(legNum int) DHChain {
+	if b.HasChain(legNum) {
+		return b.Chains[legNum]
+	}
+	return NewStandardChain(b.Segments[legNum])
+}
+
+// GetSegmentLengths returns the segment lengths for a given leg. The lengths of a leg defined by a
+// standard coxa, femur and tibia chain are the link lengths of the chain, so the closed form IK solver
+// and the forward kinematics use the same leg
+func (b *BodyDefinition) GetSegmentLengths(legNum int) SegmentLengths {
+	if b.HasChain(legNum) {
+		return b.Chains[legNum].SegmentLengths()
+	}
+	return b.Segments[legNum]
+}
+
+// GetKneeConfiguration returns the knee configuration for a given leg
+func (b *BodyDefinition) GetKneeConfiguration(legNum int) KneeConfiguration {
+	if legNum < len(b.Knees) {
+		return b.Knees[legNum]
+	}
+	return KneeUp
+}
+
+// GetJointLimits returns the joint limits for a given leg
+func (b *BodyDefinition) GetJointLimits(legNum int) JointLimits {
+	if legNum < len(b.JointLimits) {
+		return b.JointLimits[legNum]
+	}
+	return JointLimits{}
 }
 
 // Save saves the current body definition to a file.
@@ -64,27 +146,65 @@ func (b *BodyDefinition) Save(filename string) error {
 // Load loads a body definition from a saved definition file.
 func (b *BodyDefinition) Load(filename string) (*BodyDefinition, error) {
 
-	fi, err := os.Open(filename)
+	buf, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
+	if len(buf) == 0 {
+		return nil, fmt.Errorf("Zero bytes read")
+	}
 
-	defer fi.Close()
-
-	buf := make([]byte, 1024)
-	n, err := fi.Read(buf)
+	var definition BodyDefinition
+	err = json.Unmarshal(buf, &definition)
 	if err != nil {
 		return nil, err
 	}
-	if n == 0 {
-		return nil, fmt.Errorf("Zero bytes read")
+
+	// Body definitions saved by older versions describe the gait with a gait pattern
+	if g := definition.Gait; g != nil && g.PhaseOffsets == nil && g.Pattern != nil {
+		definition.Gait, err = NewGaitFromPattern(g.Name, *g.Pattern, g.NumIndicesInPattern, definition.NumLegs)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert the gait pattern in %s: %w", filename, err)
+		}
 	}
 
-	var definition BodyDefinition
-	err = json.Unmarshal(buf[:n], &definition)
+	err = definition.Validate()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid body definition in %s: %w", filename, err)
 	}
 
 	return &definition, nil
 }
+
+// Validate returns an error if the body definition is inconsistent: the number of legs does not match the
+// legs defined, or an optional per leg entry (joint limits, chains, knees) is outside the legs or unusable
+func (b *BodyDefinition) Validate() error {
+	if b.NumLegs < 1 {
+		return fmt.Errorf("the pod must have at least one leg (NumLegs was %d)", b.NumLegs)
+	}
+	if len(b.CoxaAngles) < b.NumLegs || len(b.CoxaCoordinates) < b.NumLegs ||
+		len(b.Segments) < b.NumLegs || len(b.RestAngles) < b.NumLegs {
+		return fmt.Errorf("CoxaAngles, CoxaCoordinates, Segments and Angles must have an entry for every leg (%d legs)", b.NumLegs)
+	}
+	if len(b.JointLimits) > b.NumLegs || len(b.Chains) > b.NumLegs || len(b.Knees) > b.NumLegs {
+		return fmt.Errorf("JointLimits, Chains and Knees can not have more entries than the number of legs (%d legs)", b.NumLegs)
+	}
+	for legNum, knee := range b.Knees {
+		if knee != KneeUp && knee != KneeDown {
+			return fmt.Errorf("leg %d: the knee configuration must be %d (up) or %d (down) (was %d)", legNum, KneeUp, KneeDown, knee)
+		}
+	}
+	for legNum, limits := range b.JointLimits {
+		joints := len(b.GetChain(legNum))
+		if limits.Len() > joints {
+			return fmt.Errorf("leg %d: joint limits for %d joints, but the leg has %d joints", legNum, limits.Len(), joints)
+		}
+		for i := 0; i < joints; i++ {
+			limit := limits.Get(i)
+			if limit.Min > limit.Max || limit.MaxSpeed < 0 {
+				return fmt.Errorf("leg %d: invalid %s limit %s", legNum, JointName(i), limit.String())
+			}
+		}
+	}
+	return nil
+}
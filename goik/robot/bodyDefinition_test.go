@@ -0,0 +1,95 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"testing"
+)
+
+func TestValidateJointLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   func() *BodyDefinition
+		limits JointLimits
+		valid  bool
+	}{
+		{"standard leg", NewExampleHexapod1, NewXL320JointLimits(), true},
+		{"2 joints, coxa and femur limited", NewTwoJointHexapod,
+			JointLimits{Coxa: JointLimit{Min: -90, Max: 90}, Femur: JointLimit{Min: -90, Max: 90, MaxSpeed: 300}}, true},
+		{"2 joints, speed limited femur only", NewTwoJointHexapod, JointLimits{Femur: JointLimit{MaxSpeed: 300}}, true},
+		{"2 joints, tibia limited", NewTwoJointHexapod, NewXL320JointLimits(), false},
+		{"2 joints, tibia speed limited", NewTwoJointHexapod, JointLimits{Tibia: JointLimit{MaxSpeed: 300}}, false},
+		{"4 joints, tarsus limited", NewTarsusHexapod,
+			JointLimits{Extra: []JointLimit{{Min: -150, Max: 150}}}, true},
+		{"4 joints, fifth joint limited", NewTarsusHexapod,
+			JointLimits{Extra: []JointLimit{{}, {Min: -150, Max: 150}}}, false},
+		{"min above max", NewExampleHexapod1, JointLimits{Femur: JointLimit{Min: 10, Max: -10}}, false},
+		{"negative speed", NewExampleHexapod1, JointLimits{Tibia: JointLimit{MaxSpeed: -1}}, false},
+	}
+
+	for _, test := range tests {
+		b := test.body()
+		b.JointLimits[0] = test.limits
+		err := b.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
+
+func TestExamplePodsAreValid(t *testing.T) {
+	bodies := map[string]func() *BodyDefinition{
+		"hexapod 0":         NewExampleHexapod0,
+		"hexapod 1":         NewExampleHexapod1,
+		"hexapod 2":         NewExampleHexapod2,
+		"pentapod":          NewExamplePentapod,
+		"heptapod":          NewHeptapod,
+		"spider":            NewSpider,
+		"tarsus hexapod":    NewTarsusHexapod,
+		"two joint hexapod": NewTwoJointHexapod,
+	}
+	for name, body := range bodies {
+		if err := body().Validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestValidateStructure(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(b *BodyDefinition)
+	}{
+		{"no legs", func(b *BodyDefinition) { b.NumLegs = 0 }},
+		{"missing segments", func(b *BodyDefinition) { b.Segments = b.Segments[:b.NumLegs-1] }},
+		{"missing rest angles", func(b *BodyDefinition) { b.RestAngles = b.RestAngles[:b.NumLegs-1] }},
+		{"invalid knee", func(b *BodyDefinition) { b.Knees = []KneeConfiguration{KneeUp, 7} }},
+		{"too many knees", func(b *BodyDefinition) { b.Knees = make([]KneeConfiguration, b.NumLegs+1) }},
+		{"too many chains", func(b *BodyDefinition) {
+			b.Chains = make([]DHChain, b.NumLegs+1)
+		}},
+	}
+
+	for _, test := range tests {
+		b := NewExampleHexapod1()
+		test.change(b)
+		if err := b.Validate(); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// BodyPose describes how the robot body is rotated and translated in relation
+// to the base reference frame. The base reference frame is the body frame of a
+// robot standing level in its neutral / rest stance, so a zero pose means that
+// the body and base reference frames coincide.
+type BodyPose struct {
+	// Rotation (in degrees) around the X (roll), Y (pitch) and Z (yaw) axes
+	Rotation Coordinate `json:"Rotation"`
+	// Translation of the body origin in the base reference frame
+	// (Remember that positive Z points towards the ground)
+	Translation Coordinate `json:"Translation"`
+}
+
+func NewBodyPose(rotation Coordinate, translation Coordinate) BodyPose {
+	return BodyPose{Rotation: rotation, Translation: translation}
+}
+
+// rotationMatrix returns R = R_Z(yaw) x R_Y(pitch) x R_X(roll)
+func (b BodyPose) rotationMatrix() *mat.Dense {
+	roll := b.Rotation.X * math.Pi / 180.0
+	pitch := b.Rotation.Y * math.Pi / 180.0
+	yaw := b.Rotation.Z * math.Pi / 180.0
+
+	R_X := mat.NewDense(3, 3, []float64{
+		1, 0, 0,
+		0, math.Cos(roll), -math.Sin(roll),
+		0, math.Sin(roll), math.Cos(roll),
+	})
+
+	R_Y := mat.NewDense(3, 3, []float64{
+		math.Cos(pitch), 0, math.Sin(pitch),
+		0, 1, 0,
+		-math.Sin(pitch), 0, math.Cos(pitch),
+	})
+
+	R_Z := mat.NewDense(3, 3, []float64{
+		math.Cos(yaw), -math.Sin(yaw), 0,
+		math.Sin(yaw), math.Cos(yaw), 0,
+		0, 0, 1,
+	})
+
+	var R_YX mat.Dense
+	R_YX.Mul(R_Y, R_X)
+	var R mat.Dense
+	R.Mul(R_Z, &R_YX)
+
+	return &R
+}
+
+// TransformationMatrix returns the homogeneous transformation matrix that maps
+// coordinates in the body reference frame into the base reference frame.
+func (b BodyPose) TransformationMatrix() *mat.Dense {
+	R := b.rotationMatrix()
+
+	return mat.NewDense(4, 4, []float64{
+		R.At(0, 0), R.At(0, 1), R.At(0, 2), b.Translation.X,
+		R.At(1, 0), R.At(1, 1), R.At(1, 2), b.Translation.Y,
+		R.At(2, 0), R.At(2, 1), R.At(2, 2), b.Translation.Z,
+		0, 0, 0, 1,
+	})
+}
+
+// ToBodyFrame maps a coordinate in the base reference frame into the body reference frame.
+// Since the transformation is a pure rotation + translation, the inverse is R^T x (c - D)
+func (b BodyPose) ToBodyFrame(c Coordinate) Coordinate {
+	R := b.rotationMatrix()
+
+	dx := c.X - b.Translation.X
+	dy := c.Y - b.Translation.Y
+	dz := c.Z - b.Translation.Z
+
+	return NewCoordinate(
+		R.At(0, 0)*dx+R.At(1, 0)*dy+R.At(2, 0)*dz,
+		R.At(0, 1)*dx+R.At(1, 1)*dy+R.At(2, 1)*dz,
+		R.At(0, 2)*dx+R.At(1, 2)*dy+R.At(2, 2)*dz)
+}
+
+// ToBaseFrame maps a coordinate in the body reference frame into the base reference frame (R x c + D)
+func (b BodyPose) ToBaseFrame(c Coordinate) Coordinate {
+	R := b.rotationMatrix()
+
+	return NewCoordinate(
+		R.At(0, 0)*c.X+R.At(0, 1)*c.Y+R.At(0, 2)*c.Z+b.Translation.X,
+		R.At(1, 0)*c.X+R.At(1, 1)*c.Y+R.At(1, 2)*c.Z+b.Translation.Y,
+		R.At(2, 0)*c.X+R.At(2, 1)*c.Y+R.At(2, 2)*c.Z+b.Translation.Z)
+}
+
+// Interpolate returns the pose a fraction t (0-1) of the way from b to target
+func (b BodyPose) Interpolate(target BodyPose, t float64) BodyPose {
+	return BodyPose{
+		Rotation: NewCoordinate(
+			b.Rotation.X+(target.Rotation.X-b.Rotation.X)*t,
+			b.Rotation.Y+(target.Rotation.Y-b.Rotation.Y)*t,
+			b.Rotation.Z+(target.Rotation.Z-b.Rotation.Z)*t),
+		Translation: NewCoordinate(
+			b.Translation.X+(target.Translation.X-b.Translation.X)*t,
+			b.Translation.Y+(target.Translation.Y-b.Translation.Y)*t,
+			b.Translation.Z+(target.Translation.Z-b.Translation.Z)*t),
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+	"testing"
+)
+
+// closeTo returns true if two coordinates are less than tolerance (mm) apart
+func closeTo(a Coordinate, b Coordinate, tolerance float64) bool {
+	return math.Abs(a.X-b.X) < tolerance && math.Abs(a.Y-b.Y) < tolerance && math.Abs(a.Z-b.Z) < tolerance
+}
+
+func TestBodyPoseFrames(t *testing.T) {
+	tests := []struct {
+		name string
+		pose BodyPose
+		body Coordinate
+		base Coordinate
+	}{
+		{"zero pose", BodyPose{}, Coordinate{10, 20, 30}, Coordinate{10, 20, 30}},
+		{"translation", NewBodyPose(Coordinate{}, Coordinate{1, 2, 3}), Coordinate{10, 20, 30}, Coordinate{11, 22, 33}},
+		{"yaw", NewBodyPose(Coordinate{Z: 90}, Coordinate{}), Coordinate{10, 0, 0}, Coordinate{0, 10, 0}},
+		{"pitch", NewBodyPose(Coordinate{Y: 90}, Coordinate{}), Coordinate{10, 0, 0}, Coordinate{0, 0, -10}},
+		{"roll", NewBodyPose(Coordinate{X: 90}, Coordinate{}), Coordinate{0, 10, 0}, Coordinate{0, 0, 10}},
+		{"roll and translation", NewBodyPose(Coordinate{X: 90}, Coordinate{0, 0, 5}), Coordinate{0, 10, 0}, Coordinate{0, 0, 15}},
+	}
+
+	for _, test := range tests {
+		base := test.pose.ToBaseFrame(test.body)
+		if !closeTo(base, test.base, 1e-9) {
+			t.Errorf("%s: %+v in the base frame is %+v, want %+v", test.name, test.body, base, test.base)
+		}
+		body := test.pose.ToBodyFrame(base)
+		if !closeTo(body, test.body, 1e-9) {
+			t.Errorf("%s: %+v in the body frame is %+v, want %+v", test.name, base, body, test.body)
+		}
+	}
+}
+
+func TestBodyPoseInterpolate(t *testing.T) {
+	from := NewBodyPose(Coordinate{0, 10, 0}, Coordinate{0, 0, 0})
+	to := NewBodyPose(Coordinate{10, 0, -20}, Coordinate{4, 0, 8})
+
+	for _, tc := range []struct {
+		t    float64
+		pose BodyPose
+	}{
+		{0, from},
+		{0.5, NewBodyPose(Coordinate{5, 5, -10}, Coordinate{2, 0, 4})},
+		{1, to},
+	} {
+		pose := from.Interpolate(to, tc.t)
+		if !closeTo(pose.Rotation, tc.pose.Rotation, 1e-9) || !closeTo(pose.Translation, tc.pose.Translation, 1e-9) {
+			t.Errorf("t = %2.2f: %+v, want %+v", tc.t, pose, tc.pose)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// DHLink describes a single revolute joint + link in a kinematic chain
+// using Denavit-Hartenberg parameters
+type DHLink struct {
+	// Joint angle offset (degrees). The servo angle is added to this angle
+	Theta float64 `json:"Theta"`
+	// Offset along the Z axis of the previous reference frame
+	D float64 `json:"D"`
+	// Link length along the X axis of the new reference frame
+	A float64 `json:"A"`
+	// Twist (degrees) around the X axis of the new reference frame
+	Alpha float64 `json:"Alpha"`
+}
+
+// DHChain describes a leg as a chain of links, starting at the coxa reference frame
+// and ending at the end effector. The number of links equals the number of servos in the leg.
+type DHChain []DHLink
+
+// NewStandardChain returns the kinematic chain of a standard coxa, femur and tibia leg.
+// The femur reference frame is rotated 90 degrees around X in relation to the coxa
+// reference frame (This is the P_Coxa projection matrix described in README.md)
+func NewStandardChain(segments SegmentLengths) DHChain {
+	return DHChain{
+		{A: segments.Coxa, Alpha: 90},
+		{A: segments.Femur},
+		{A: segments.Tibia},
+	}
+}
+
+// IsStandard returns true if the chain is a standard coxa, femur and tibia leg.
+// The closed form IK solver can only be used for these legs.
+func (c DHChain) IsStandard() bool {
+	if len(c) != 3 {
+		return false
+	}
+	for i, link := range c {
+		if link.Theta != 0 || link.D != 0 {
+			return false
+		}
+		if (i == 0 && link.Alpha != 90) || (i > 0 && link.Alpha != 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// SegmentLengths returns the coxa, femur and tibia lengths (the link lengths A) of a standard chain (see IsStandard)
+func (c DHChain) SegmentLengths() SegmentLengths {
+	if !c.IsStandard() {
+		return SegmentLengths{}
+	}
+	return SegmentLengths{Coxa: c[0].A, Femur: c[1].A, Tibia: c[2].A}
+}
+
+// Frames returns the homogeneous transformation matrices for all reference frames in the chain
+// (coxa origin, ..., end effector) given the base transformation for the first frame and the joint
+// angles (in degrees)
+func (c DHChain) Frames(base *mat.Dense, angles []float64) []*mat.Dense {
+	frames := make([]*mat.Dense, len(c)+1)
+	frames[0] = base
+
+	for i, link := range c {
+		var H mat.Dense
+		H.Mul(frames[i], DenavitHartenbergMatrix((link.Theta+angles[i])*math.Pi/180.0, link.D, link.A, link.Alpha*math.Pi/180.0))
+		frames[i+1] = &H
+	}
+	return frames
+}
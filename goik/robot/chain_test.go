@@ -0,0 +1,107 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestChainIsStandard(t *testing.T) {
+	segments := SegmentLengths{Coxa: 30, Femur: 60, Tibia: 70}
+	tests := []struct {
+		name     string
+		chain    DHChain
+		standard bool
+	}{
+		{"standard", NewStandardChain(segments), true},
+		{"standard, other lengths", DHChain{{A: 10, Alpha: 90}, {A: 20}, {A: 30}}, true},
+		{"tarsus", append(NewStandardChain(segments), DHLink{A: 50}), false},
+		{"2 joints", DHChain{{A: 30, Alpha: 90}, {A: 100}}, false},
+		{"offset", DHChain{{A: 30, Alpha: 90}, {A: 60, D: 5}, {A: 70}}, false},
+		{"twisted tibia", DHChain{{A: 30, Alpha: 90}, {A: 60}, {A: 70, Alpha: 10}}, false},
+	}
+
+	for _, test := range tests {
+		if test.chain.IsStandard() != test.standard {
+			t.Errorf("%s: standard %t, want %t", test.name, !test.standard, test.standard)
+		}
+		lengths := test.chain.SegmentLengths()
+		if test.standard && (lengths.Coxa != test.chain[0].A || lengths.Femur != test.chain[1].A || lengths.Tibia != test.chain[2].A) {
+			t.Errorf("%s: segment lengths %+v", test.name, lengths)
+		}
+		if !test.standard && lengths != (SegmentLengths{}) {
+			t.Errorf("%s: segment lengths %+v, want none", test.name, lengths)
+		}
+	}
+}
+
+// The frames of a standard chain follow the coxa, femur and tibia of the leg
+func TestChainFrames(t *testing.T) {
+	chain := NewStandardChain(SegmentLengths{Coxa: 30, Femur: 60, Tibia: 70})
+	base := mat.NewDense(4, 4, []float64{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1})
+	tests := []struct {
+		angles []float64
+		joints []Coordinate
+	}{
+		{[]float64{0, 0, 0}, []Coordinate{{}, {30, 0, 0}, {90, 0, 0}, {160, 0, 0}}},
+		{[]float64{90, 0, 0}, []Coordinate{{}, {0, 30, 0}, {0, 90, 0}, {0, 160, 0}}},
+		{[]float64{0, 0, 90}, []Coordinate{{}, {30, 0, 0}, {90, 0, 0}, {90, 0, 70}}},
+		{[]float64{0, 90, -90}, []Coordinate{{}, {30, 0, 0}, {30, 0, 60}, {100, 0, 60}}},
+	}
+
+	for _, test := range tests {
+		frames := chain.Frames(base, test.angles)
+		if len(frames) != len(chain)+1 {
+			t.Fatalf("%d frames, want %d", len(frames), len(chain)+1)
+		}
+		for i, frame := range frames {
+			origin := Coordinate{frame.At(0, 3), frame.At(1, 3), frame.At(2, 3)}
+			if math.Abs(origin.X-test.joints[i].X) > 1e-9 || math.Abs(origin.Y-test.joints[i].Y) > 1e-9 ||
+				math.Abs(origin.Z-test.joints[i].Z) > 1e-9 {
+				t.Errorf("angles %v, frame %d at %+v, want %+v", test.angles, i, origin, test.joints[i])
+			}
+		}
+	}
+}
+
+// Legs defined by a kinematic chain take their segment lengths from the chain
+func TestSetSegmentLengthOfChain(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  func() *BodyDefinition
+		valid bool
+	}{
+		{"hexapod 1", NewExampleHexapod1, true},
+		{"tarsus hexapod", NewTarsusHexapod, false},
+		{"two joint hexapod", NewTwoJointHexapod, false},
+	}
+
+	for _, test := range tests {
+		p := NewPod(test.body())
+		setters := []func(int, float64) error{p.SetCoxaLength, p.SetFemurLength, p.SetTibiaLength}
+		for i, set := range setters {
+			err := set(0, 42)
+			if test.valid && err != nil {
+				t.Errorf("%s, %s: %v", test.name, JointName(i), err)
+			}
+			if !test.valid && err == nil {
+				t.Errorf("%s, %s: no error", test.name, JointName(i))
+			}
+		}
+	}
+}
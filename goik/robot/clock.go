@@ -0,0 +1,134 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+/*
+	Notes regarding the simulation clock
+
+	1) The pod moves in fixed time steps (ticks). Every call to Pod.Update advances the interpolation
+	   one tick, and a tick lasts dt = 1 / rate seconds of simulated time. Speeds (mm/s and deg/s) are
+	   converted to distances per tick with dt, so the motion does not depend on how often the pod is drawn.
+	2) The caller measures the elapsed time and asks the clock how many ticks are due (see Advance).
+	   Time left over is carried to the next call, so the average tick rate is exact.
+	3) Motion primitives are recorded once per tick, so the tick rate is the sample rate of a primitive.
+	   The servo angles are streamed at the same rate.
+*/
+
+// Default rate (ticks per second) of the simulation clock
+const DEFAULT_TICK_RATE = 50.0
+
+// Highest usable rate (ticks per second) of the simulation clock
+const MAX_TICK_RATE = 1000.0
+
+// Highest number of ticks returned by a single call to Advance. A caller that falls further behind
+// (a paused window, a debugger etc.) drops the remaining time instead of trying to catch up all at once
+const MAX_CATCH_UP_TICKS = 100
+
+// Clock is a fixed time step simulation clock (see the notes above)
+type Clock struct {
+	// Ticks per second
+	Rate float64
+	// Simulated time (seconds) since the clock started
+	Time float64
+	// Number of ticks since the clock started
+	Ticks int
+	// Elapsed time (seconds) not yet used by a tick
+	pending float64
+}
+
+// NewClock returns a clock with a given rate (ticks per second)
+func NewClock(rate float64) (*Clock, error) {
+	err := validateTickRate(rate)
+	if err != nil {
+		return nil, err
+	}
+	return &Clock{Rate: rate}, nil
+}
+
+// validateTickRate returns an error if a tick rate is unusable
+func validateTickRate(rate float64) error {
+	if rate < 1 || rate > MAX_TICK_RATE {
+		return fmt.Errorf("the tick rate must be between 1 and %2.0f Hz (was %2.2f)", MAX_TICK_RATE, rate)
+	}
+	return nil
+}
+
+// Dt returns the duration (seconds) of a tick
+func (c *Clock) Dt() float64 {
+	return 1 / c.Rate
+}
+
+// Advance moves the clock forward by the elapsed (simulated) time and returns the number of ticks that are due
+func (c *Clock) Advance(elapsed time.Duration) int {
+	c.pending += elapsed.Seconds()
+	ticks := int(math.Floor(c.pending*c.Rate + 1e-9))
+	if ticks > MAX_CATCH_UP_TICKS {
+		ticks = MAX_CATCH_UP_TICKS
+		c.pending = 0
+	} else {
+		c.pending = math.Max(0, c.pending-float64(ticks)*c.Dt())
+	}
+	for i := 0; i < ticks; i++ {
+		c.Tick()
+	}
+	return ticks
+}
+
+// Tick moves the clock forward one tick
+func (c *Clock) Tick() {
+	c.Ticks++
+	c.Time += c.Dt()
+}
+
+// SetTickRate changes the rate (ticks per second) of the simulation clock. The gait cycle keeps its number
+// of ticks, so it lasts a shorter or longer time, while velocities (mm/s and deg/s) keep their meaning.
+// A walking pod switches to the new timing at the next compatible phase of the gait (see SetGait).
+// The rate can not be changed while recording, since a motion primitive has a single sample rate
+func (p *Pod) SetTickRate(rate float64) error {
+	err := validateTickRate(rate)
+	if err != nil {
+		return err
+	}
+	if p.IsRecording && rate != p.Clock.Rate {
+		return fmt.Errorf("stop recording before changing the tick rate (recording at %2.2f Hz)", p.Clock.Rate)
+	}
+
+	gait := p.NextGait()
+	if gait.TickRate == rate {
+		p.Clock.Rate = rate
+		return nil
+	}
+	retimed := *gait
+	err = retimed.Validate(len(p.Legs))
+	if err != nil {
+		return err
+	}
+
+	// The gait is timed by the clock (see timeGait). The previous rate is kept if the gait is rejected
+	previous := p.Clock.Rate
+	p.Clock.Rate = rate
+	err = p.SetGait(&retimed)
+	if err != nil {
+		p.Clock.Rate = previous
+		return err
+	}
+	return nil
+}
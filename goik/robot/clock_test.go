@@ -0,0 +1,85 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockAdvance(t *testing.T) {
+	tests := []struct {
+		rate    float64
+		elapsed []time.Duration
+		ticks   []int
+	}{
+		{50, []time.Duration{20 * time.Millisecond, 20 * time.Millisecond}, []int{1, 1}},
+		{50, []time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 30 * time.Millisecond}, []int{0, 1, 1}},
+		{100, []time.Duration{55 * time.Millisecond, 5 * time.Millisecond}, []int{5, 1}},
+		{50, []time.Duration{10 * time.Second}, []int{MAX_CATCH_UP_TICKS}},
+	}
+
+	for _, test := range tests {
+		c, err := NewClock(test.rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total := 0
+		for i, elapsed := range test.elapsed {
+			ticks := c.Advance(elapsed)
+			if ticks != test.ticks[i] {
+				t.Errorf("%2.0f Hz, step %d: %d ticks, want %d", test.rate, i, ticks, test.ticks[i])
+			}
+			total += ticks
+		}
+		if c.Ticks != total {
+			t.Errorf("%2.0f Hz: the clock counted %d ticks, want %d", test.rate, c.Ticks, total)
+		}
+	}
+}
+
+func TestSetTickRate(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	rate := p.Clock.Rate
+
+	if err := p.SetTickRate(2 * rate); err != nil {
+		t.Fatal(err)
+	}
+	if p.Clock.Rate != 2*rate || p.NextGait().TickRate != 2*rate {
+		t.Errorf("clock at %2.2f Hz and gait at %2.2f Hz, want %2.2f Hz", p.Clock.Rate, p.NextGait().TickRate, 2*rate)
+	}
+
+	for _, invalid := range []float64{0, MAX_TICK_RATE + 1} {
+		if err := p.SetTickRate(invalid); err == nil {
+			t.Errorf("%2.2f Hz: no error", invalid)
+		}
+	}
+
+	// A gait that can not be retimed leaves the clock and the gait at the same rate
+	gait := *p.NextGait()
+	gait.PhaseOffsets = gait.PhaseOffsets[1:]
+	p.BodyDefinition.Gait = &gait
+	if err := p.SetTickRate(rate); err == nil {
+		t.Error("no error retiming an invalid gait")
+	}
+	if p.Clock.Rate != 2*rate || p.NextGait().TickRate != 2*rate {
+		t.Errorf("clock at %2.2f Hz and gait at %2.2f Hz, want %2.2f Hz", p.Clock.Rate, p.NextGait().TickRate, 2*rate)
+	}
+
+	p.StartRecording()
+	if err := p.SetTickRate(rate); err == nil {
+		t.Error("no error changing the rate while recording")
+	}
+}
@@ -32,6 +32,7 @@ func NewExampleHexapod0() *BodyDefinition {
 	for i := 0; i < b.NumLegs; i++ {
 		b.RestAngles = append(b.RestAngles, ServoAngles{Coxa: 0, Femur: -50, Tibia: 100})
 		b.Segments = append(b.Segments, SegmentLengths{Coxa: 30, Femur: 70, Tibia: 120})
+		b.JointLimits = append(b.JointLimits, NewXL320JointLimits())
 	}
 	return b
 }
@@ -54,6 +55,12 @@ func NewExampleHexapod1() *BodyDefinition {
 	for i := 0; i < b.NumLegs; i++ {
 		b.RestAngles = append(b.RestAngles, ServoAngles{Coxa: 0, Femur: -50, Tibia: 100})
 		b.Segments = append(b.Segments, SegmentLengths{Coxa: 30, Femur: 70, Tibia: 120})
+		b.JointLimits = append(b.JointLimits, NewXL320JointLimits())
+	}
+
+	b.Masses = &MassProperties{Body: 150}
+	for i := 0; i < b.NumLegs; i++ {
+		b.Masses.Legs = append(b.Masses.Legs, NewXL320LegMasses(5, 10, 15))
 	}
 
 	return b
@@ -78,6 +85,7 @@ func NewExampleHexapod2() *BodyDefinition {
 	for i := 0; i < b.NumLegs; i++ {
 		b.RestAngles = append(b.RestAngles, ServoAngles{Coxa: 0, Femur: 45, Tibia: 45})
 		b.Segments = append(b.Segments, SegmentLengths{Coxa: 53.85, Femur: 48, Tibia: 61.7})
+		b.JointLimits = append(b.JointLimits, NewXL320JointLimits())
 	}
 
 	return b
@@ -101,6 +109,7 @@ func NewExamplePentapod() *BodyDefinition {
 	for i := 0; i < b.NumLegs; i++ {
 		b.RestAngles = append(b.RestAngles, ServoAngles{Coxa: 0, Femur: -50, Tibia: 100})
 		b.Segments = append(b.Segments, SegmentLengths{Coxa: 40, Femur: 60, Tibia: 150})
+		b.JointLimits = append(b.JointLimits, NewXL320JointLimits())
 	}
 
 	return b
@@ -127,6 +136,7 @@ func NewHeptapod() *BodyDefinition {
 	for i := 0; i < b.NumLegs; i++ {
 		b.RestAngles = append(b.RestAngles, ServoAngles{Coxa: 0, Femur: -50, Tibia: 100})
 		b.Segments = append(b.Segments, SegmentLengths{Coxa: 40, Femur: 60, Tibia: 150})
+		b.JointLimits = append(b.JointLimits, NewXL320JointLimits())
 	}
 
 	return b
@@ -134,7 +144,7 @@ func NewHeptapod() *BodyDefinition {
 
 // Example (spider like) pod with 8 legs and varying segment lengths
 func NewSpider() *BodyDefinition {
-	gait, _ := NewHexapodGait(TRIPOD)
+	gait, _ := NewGait(8, TRIPOD)
 	b := &BodyDefinition{
 		NumLegs:    8,
 		CoxaAngles: []float64{20, 70, 110, 150, 210, 250, 290, 330},
@@ -161,6 +171,50 @@ func NewSpider() *BodyDefinition {
 
 	for i := 0; i < b.NumLegs; i++ {
 		b.RestAngles = append(b.RestAngles, ServoAngles{Coxa: 0, Femur: -50, Tibia: 100})
+		b.JointLimits = append(b.JointLimits, NewXL320JointLimits())
+	}
+
+	return b
+}
+
+// Example pod with 6 legs where every leg has an additional tarsus joint (4 servos per leg).
+// The legs are described by a kinematic chain, and the IK is solved numerically.
+func NewTarsusHexapod() *BodyDefinition {
+	b := NewExampleHexapod1()
+
+	for i := 0; i < b.NumLegs; i++ {
+		b.RestAngles[i] = ServoAngles{Coxa: 0, Femur: -40, Tibia: 70, Extra: []float64{40}}
+		b.Segments[i] = SegmentLengths{Coxa: 30, Femur: 60, Tibia: 70}
+		b.Chains = append(b.Chains, DHChain{
+			{A: 30, Alpha: 90},
+			{A: 60},
+			{A: 70},
+			{A: 50},
+		})
+		b.JointLimits[i].Extra = []JointLimit{{Min: -150, Max: 150}}
+	}
+
+	return b
+}
+
+// Example pod with 6 legs where every leg only has a coxa and a femur joint (2 servos per leg).
+// The femur ends at the foot. The legs are described by a kinematic chain, and the IK is solved numerically.
+// A foot can only reach the points at a fixed distance from the femur joint, so the legs can be posed and
+// recorded, but they are unable to follow a flat stride path.
+func NewTwoJointHexapod() *BodyDefinition {
+	b := NewExampleHexapod1()
+
+	for i := 0; i < b.NumLegs; i++ {
+		b.RestAngles[i] = ServoAngles{Coxa: 0, Femur: 60}
+		b.Segments[i] = SegmentLengths{Coxa: 30, Femur: 100}
+		b.Chains = append(b.Chains, DHChain{
+			{A: 30, Alpha: 90},
+			{A: 100},
+		})
+		b.JointLimits[i] = JointLimits{
+			Coxa:  JointLimit{Min: -150, Max: 150},
+			Femur: JointLimit{Min: -150, Max: 150},
+		}
 	}
 
 	return b
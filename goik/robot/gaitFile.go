@@ -0,0 +1,125 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// GaitFile is the JSON representation of a user defined gait. The timing of the legs is given
+// either by phase offsets or by a gait pattern (one row per leg, 1 == swing phase, 0 == stance phase).
+//
+// Example (tripod gait for a hexapod):
+//
+//	{
+//		"Name": "My tripod",
+//		"PhaseOffsets": [0, 0.5, 0, 0.5, 0, 0.5],
+//		"DutyFactor": 0.5,
+//		"LiftHeight": 40,
+//		"SwingProfile": {"Type": "rectangular", "TouchdownSpeed": 1}
+//	}
+type GaitFile struct {
+	Name string
+	// Phase (0-1) in the gait cycle where each leg lifts off
+	PhaseOffsets []float64 `json:",omitempty"`
+	// Binary gait table. Each leg must swing in one continuous run of columns
+	Pattern GaitPattern `json:",omitempty"`
+	// Fraction of the gait cycle each leg spends in the stance phase. Overrides the
+	// duty factor given by the gait pattern or the stance return factor
+	DutyFactor float64 `json:",omitempty"`
+	// Speed of the stance phase relative to the swing phase (1 == the legs move back through
+	// the stance phase as fast as they move forward through the swing phase).
+	// Used if no duty factor is given
+	StanceReturnFactor float64 `json:",omitempty"`
+	// Maximum height of the swing phase arc (Z_LIFT is used if 0)
+	LiftHeight float64 `json:",omitempty"`
+	// Trajectory of the end effectors in the swing phase (see SwingProfile), for all legs and for individual legs
+	SwingProfile     *SwingProfile   `json:",omitempty"`
+	LegSwingProfiles []*SwingProfile `json:",omitempty"`
+}
+
+// Gait converts the gait file to a gait for a pod with a given number of legs
+func (f *GaitFile) Gait(NumLegs int) (*Gait, error) {
+	var gait *Gait
+	var err error
+
+	switch {
+	case f.PhaseOffsets != nil && f.Pattern != nil:
+		return nil, fmt.Errorf("the gait must be described by either phase offsets or a gait pattern (not both)")
+	case f.Pattern != nil:
+		if len(f.Pattern) != NumLegs {
+			return nil, fmt.Errorf("the gait pattern has %d rows. The pod has %d legs", len(f.Pattern), NumLegs)
+		}
+		for l := range f.Pattern {
+			if len(f.Pattern[l]) != len(f.Pattern[0]) {
+				return nil, fmt.Errorf("all rows in the gait pattern must have the same length")
+			}
+		}
+		gait, err = NewGaitFromPattern(f.Name, f.Pattern, len(f.Pattern[0]), NumLegs)
+		if err != nil {
+			return nil, err
+		}
+	case f.PhaseOffsets != nil:
+		gait = &Gait{Name: f.Name, PhaseOffsets: f.PhaseOffsets}
+		if f.DutyFactor == 0 && f.StanceReturnFactor == 0 {
+			return nil, fmt.Errorf("a gait described by phase offsets needs a duty factor or a stance return factor")
+		}
+	default:
+		return nil, fmt.Errorf("the gait must be described by either phase offsets or a gait pattern")
+	}
+
+	if f.DutyFactor != 0 {
+		gait.DutyFactor = f.DutyFactor
+	} else if f.StanceReturnFactor < 0 {
+		return nil, fmt.Errorf("the stance return factor must be positive (was %2.2f)", f.StanceReturnFactor)
+	} else if f.StanceReturnFactor > 0 {
+		// The stance phase lasts 1 / StanceReturnFactor times as long as the swing phase
+		gait.DutyFactor = 1 / (1 + f.StanceReturnFactor)
+	}
+	gait.LiftHeight = f.LiftHeight
+	gait.SwingProfile = f.SwingProfile
+	gait.LegSwingProfiles = f.LegSwingProfiles
+
+	return gait, gait.Validate(NumLegs)
+}
+
+// LoadGait loads a user defined gait from a gait file (see GaitFile) and validates it
+// against the number of legs of a pod
+func LoadGait(filename string, NumLegs int) (*Gait, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) == 0 {
+		return nil, fmt.Errorf("Zero bytes read")
+	}
+
+	var f GaitFile
+	err = json.Unmarshal(buf, &f)
+	if err != nil {
+		return nil, err
+	}
+	if f.Name == "" {
+		return nil, fmt.Errorf("the gait in %s has no name", filename)
+	}
+
+	gait, err := f.Gait(NumLegs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gait in %s: %w", filename, err)
+	}
+	return gait, nil
+}
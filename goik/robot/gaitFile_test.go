@@ -0,0 +1,88 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestGaitFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		file         GaitFile
+		numLegs      int
+		dutyFactor   float64
+		phaseOffsets []float64
+		valid        bool
+	}{
+		{"phase offsets", GaitFile{Name: "tripod", PhaseOffsets: []float64{0, 0.5, 0, 0.5}, DutyFactor: 0.5},
+			4, 0.5, []float64{0, 0.5, 0, 0.5}, true},
+		{"stance return factor", GaitFile{Name: "slow", PhaseOffsets: []float64{0, 0.5}, StanceReturnFactor: 1},
+			2, 0.5, []float64{0, 0.5}, true},
+		{"pattern", GaitFile{Name: "wave", Pattern: GaitPattern{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}},
+			3, 2.0 / 3, []float64{0, 1.0 / 3, 2.0 / 3}, true},
+		{"no duty factor", GaitFile{Name: "tripod", PhaseOffsets: []float64{0, 0.5}}, 2, 0, nil, false},
+		{"offsets and pattern", GaitFile{Name: "both", PhaseOffsets: []float64{0, 0.5}, Pattern: GaitPattern{{1, 0}, {0, 1}}, DutyFactor: 0.5},
+			2, 0, nil, false},
+		{"too few legs", GaitFile{Name: "wave", Pattern: GaitPattern{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}}, 4, 0, nil, false},
+		{"ragged pattern", GaitFile{Name: "wave", Pattern: GaitPattern{{1, 0, 0}, {0, 1}}}, 2, 0, nil, false},
+		{"negative stance return factor", GaitFile{Name: "slow", PhaseOffsets: []float64{0, 0.5}, StanceReturnFactor: -1},
+			2, 0, nil, false},
+	}
+
+	for _, test := range tests {
+		gait, err := test.file.Gait(test.numLegs)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%s: no error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if math.Abs(gait.DutyFactor-test.dutyFactor) > 1e-9 {
+			t.Errorf("%s: duty factor %2.3f, want %2.3f", test.name, gait.DutyFactor, test.dutyFactor)
+		}
+		for l, offset := range test.phaseOffsets {
+			if math.Abs(gait.PhaseOffsets[l]-offset) > 1e-9 {
+				t.Errorf("%s: phase offsets %v, want %v", test.name, gait.PhaseOffsets, test.phaseOffsets)
+				break
+			}
+		}
+	}
+}
+
+// The example gait file matches the generated tripod gait
+func TestLoadExampleGait(t *testing.T) {
+	gait, err := LoadGait("../gaits/tripod.json", 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tripod, err := NewHexapodGait(TRIPOD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gait.DutyFactor != tripod.DutyFactor || !reflect.DeepEqual(gait.PhaseOffsets, tripod.PhaseOffsets) {
+		t.Errorf("loaded %+v, want the timing of %+v", gait, tripod)
+	}
+
+	if _, err := LoadGait("../gaits/tripod.json", 5); err == nil {
+		t.Error("no error loading a hexapod gait for 5 legs")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import "math"
+
+/*
+	Notes regarding gait transitions
+
+	1) The pod keeps walking while the gait changes. The new gait is pending until a compatible
+	   phase of the current gait, where no leg is lifted off the ground (typically the moment
+	   one group of legs touches down and the next group lifts off).
+	2) If no compatible phase occurs within a full cycle of the current gait, the gait is switched
+	   anyway. Legs in the air complete their swing phase arc before they join the stance phase
+	   of the new gait (an intermediate stepping sequence).
+	3) In the velocity mode (see SetVelocity), the legs plan their strides for the new gait as they lift off.
+	4) The new gait starts at the phase of its cycle that best matches where the legs are on
+	   their stride paths. The legs then catch up with the new gait over their current phase.
+	   No leg jumps, so recorded motion primitives stay continuous across the switch.
+*/
+
+// SetGait switches the pod to a new gait. A walking pod keeps walking, and the switch
+// happens at a compatible phase of the current gait (see UpdateMovement).
+// A gait without its own number of interpolation steps uses the steps of the pod, and
+// every gait is timed for the rate of the pod clock.
+func (p *Pod) SetGait(gait *Gait) error {
+	err := gait.Validate(len(p.Legs))
+	if err != nil {
+		return err
+	}
+	p.timeGait(gait)
+
+	if !p.IsWalking() {
+		p.pendingGait = nil
+		p.switchGait(gait)
+		return nil
+	}
+	p.pendingGait = gait
+	p.pendingGaitTicks = 0
+	return nil
+}
+
+// timeGait times a gait for the pod: a gait without its own number of interpolation steps uses the steps
+// of the pod, and the ticks of the gait last as long as the ticks of the pod clock
+func (p *Pod) timeGait(gait *Gait) {
+	if gait.InterpolationSteps == 0 {
+		gait.InterpolationSteps = p.InterpolationSteps
+	}
+	gait.TickRate = p.Clock.Rate
+}
+
+// IsChangingGait returns true if a new gait is waiting for a compatible phase of the current gait
+func (p *Pod) IsChangingGait() bool {
+	return p.pendingGait != nil
+}
+
+// NextGait returns the gait the pod will be walking with once a pending gait transition is done
+func (p *Pod) NextGait() *Gait {
+	if p.pendingGait != nil {
+		return p.pendingGait
+	}
+	return p.BodyDefinition.Gait
+}
+
+// simulationTicks returns the number of ticks needed for simulating two full cycles of the
+// next gait (including a full cycle of the current gait while a gait transition is pending)
+func (p *Pod) simulationTicks() int {
+	ticks := 2 * p.NextGait().CycleTicks()
+	if p.pendingGait != nil {
+		ticks += p.BodyDefinition.Gait.CycleTicks()
+	}
+	return ticks
+}
+
+// updateGaitTransition switches to the pending gait when no leg is lifted off the ground,
+// or when the pending gait has waited for a full cycle of the current gait
+func (p *Pod) updateGaitTransition() {
+	if p.pendingGait == nil {
+		return
+	}
+
+	compatible := true
+	for _, l := range p.Legs {
+		if l.IsLifted() {
+			compatible = false
+		}
+	}
+
+	if compatible || p.pendingGaitTicks >= p.BodyDefinition.Gait.CycleTicks() {
+		gait := p.pendingGait
+		p.pendingGait = nil
+		p.switchGait(gait)
+		return
+	}
+	p.pendingGaitTicks++
+}
+
+// switchGait replaces the current gait, starting at the phase of the new gait cycle
+// that best matches the current positions of the legs
+func (p *Pod) switchGait(gait *Gait) {
+	p.BodyDefinition.Gait = gait
+	p.CyclePhase = p.matchPhase(gait)
+	p.cycleProgress = 0
+}
+
+// matchPhase returns the phase of a gait cycle where the legs of a gait in steady state
+// are closest to the current positions of the legs on their stride paths.
+// Legs in the air should preferably be in the swing phase.
+func (p *Pod) matchPhase(gait *Gait) float64 {
+	best := 0.0
+	bestCost := math.Inf(1)
+	for tick := 0; tick < gait.CycleTicks(); tick++ {
+		phase := float64(tick) * gait.PhaseStep()
+		cost := 0.0
+		for i, l := range p.Legs {
+			legPhase := gait.LegPhase(i, phase)
+			var position float64
+			if gait.IsSwing(legPhase) {
+				position = legPhase / (1 - gait.DutyFactor)
+			} else {
+				position = 1 - (legPhase-(1-gait.DutyFactor))/gait.DutyFactor
+				if l.IsLifted() {
+					cost += 1
+				}
+			}
+			cost += (position - l.pathPosition) * (position - l.pathPosition)
+		}
+		if cost < bestCost {
+			best = phase
+			bestCost = cost
+		}
+	}
+	return best
+}
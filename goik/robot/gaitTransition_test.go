@@ -0,0 +1,104 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+	"testing"
+)
+
+// A walking pod switches gait within a cycle of the current gait, and no leg jumps at the switch
+func TestGaitTransition(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	if err := p.SetStrideVector(4, 20, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	// Walk into the swing phase of the tripod gait, so that the switch has to wait
+	for i := 0; i < 3; i++ {
+		p.Clock.Tick()
+		p.Update()
+	}
+
+	ripple, err := NewHexapodGait(RIPPLE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tripod := p.BodyDefinition.Gait
+	if err := p.SetGait(ripple); err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsChangingGait() || p.BodyDefinition.Gait != tripod || p.NextGait() != ripple {
+		t.Fatal("the gait changed mid swing")
+	}
+
+	// The largest distance a foot moves in a tick while walking with the tripod gait
+	maxStep := 0.0
+	feet := p.GetEndEffectorPositions()
+	step := func() {
+		p.Clock.Tick()
+		p.Update()
+		for i, c := range p.GetEndEffectorPositions() {
+			d := math.Sqrt((c.X-feet[i].X)*(c.X-feet[i].X) + (c.Y-feet[i].Y)*(c.Y-feet[i].Y) + (c.Z-feet[i].Z)*(c.Z-feet[i].Z))
+			if p.IsChangingGait() {
+				maxStep = math.Max(maxStep, d)
+			} else if d > GAIT_CATCH_UP_FACTOR*maxStep+1e-6 {
+				t.Errorf("leg %d moved %2.2f mm in a tick after the switch (%2.2f mm before)", i, d, maxStep)
+			}
+			feet[i] = c
+		}
+	}
+
+	ticks := 0
+	for ; p.IsChangingGait(); ticks++ {
+		if ticks > tripod.CycleTicks() {
+			t.Fatalf("the gait did not change within a cycle (%d ticks)", tripod.CycleTicks())
+		}
+		step()
+	}
+	if p.BodyDefinition.Gait != ripple {
+		t.Errorf("walking with %s, want %s", p.BodyDefinition.Gait.Name, ripple.Name)
+	}
+	for i := 0; i < ripple.CycleTicks(); i++ {
+		step()
+	}
+}
+
+func TestSetGaitWhileIdle(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	wave, err := NewHexapodGait(WAVE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetGait(wave); err != nil {
+		t.Fatal(err)
+	}
+	if p.IsChangingGait() || p.BodyDefinition.Gait != wave {
+		t.Error("an idle pod did not switch gait at once")
+	}
+
+	pentapod, err := NewPentapodGait(WAVE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetGait(pentapod); err == nil {
+		t.Error("no error switching a hexapod to a pentapod gait")
+	}
+	if p.BodyDefinition.Gait != wave {
+		t.Error("an invalid gait replaced the gait of the pod")
+	}
+}
@@ -17,139 +17,380 @@ package robot
 /*
 	Notes regarding gaits
 
-	1) Yes, these patterns could be created algorithmically, but it would make the code harder to read.
-	   It also makes my head hurt.
-	2) Currently supported gaits:
-		- Hexapods: 									tripod, ripple and wave gait.
-		- <Odd number>pods (pentapods/heptapods):	    wave gait.
-		- <Even number>pods (hexapod): 	                wave gait, ripple gait.
-		  (gait type is still an argument for constructing these patterns, since someone just might come
-		   up with some clever new gait)
-	3) Yes, a symmetrical centipede with metachronal gait would be nice. Unfortunately I've run out of dynamixels
+	1) A gait is described by a duty factor and a phase offset for each leg on a continuous gait cycle clock
+	   (0-1). The duty factor is the fraction of the gait cycle a leg spends in the stance phase, and the phase
+	   offset is the point in the gait cycle where the leg lifts off and starts the swing phase.
+	   Swing phases may overlap by any fraction of a step.
+	2) Gaits are generated for any number of legs from a leg ordering (the legs on the left and right
+	   side of the body, ordered front to back).
+	3) Generated gaits:
+		- Wave:        One leg swings at a time. The wave moves from the rear to the front on the left
+		               side, and then from the rear to the front on the right side.
+		- Ripple:      One leg on each side swings at a time. Each side runs a rear to front wave over N parts
+		               of the cycle (N legs per side), and the right side is N/2 parts (rounded down) behind
+		               the left side. With three legs per side that is a third of a cycle, as in the classic
+		               hexapod ripple gait.
+		- Tripod:      Two alternating groups of legs (the tripod equivalent for any number of legs).
+		               Neighbouring legs on the same side, and opposite legs, are in different groups.
+		- Metachronal: Several rear to front waves of METACHRONAL_WAVE_LENGTH legs travel along each side at
+		               the same time, with the right side shifted the same way as the ripple gait. With no more
+		               than METACHRONAL_WAVE_LENGTH legs per side a single wave fits each side, and the gait is
+		               the same as the ripple gait. (Yes, a symmetrical centipede with metachronal gait would
+		               be nice. Unfortunately I've run out of dynamixels)
+	   The generated gaits divide the gait cycle into N equal parts, and each leg swings in one of them.
+	   The duty factor is then (N - 1) / N.
 */
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+)
 
 type GaitType int
 
 const (
-	TRIPOD GaitType = 0
-	WAVE   GaitType = 1
-	RIPPLE GaitType = 2
+	TRIPOD      GaitType = 0
+	WAVE        GaitType = 1
+	RIPPLE      GaitType = 2
+	METACHRONAL GaitType = 3
 )
 
+func (g GaitType) String() string {
+	switch g {
+	case TRIPOD:
+		return "Tripod"
+	case WAVE:
+		return "Wave"
+	case RIPPLE:
+		return "Ripple"
+	case METACHRONAL:
+		return "Metachronal"
+	}
+	return fmt.Sprintf("GaitType(%d)", int(g))
+}
+
+// Number of legs on each side of the body in a single wave of the metachronal gait
+// (if the body has enough legs)
+const METACHRONAL_WAVE_LENGTH = 3
+
+// Legs catching up with a gait (after starting from the neutral stance, or after a gait transition)
+// move along the stride path at most GAIT_CATCH_UP_FACTOR times as fast as in the fastest phase of the gait
+const GAIT_CATCH_UP_FACTOR = 2.0
+
+// Vertical speed (mm/s) of the end effectors lowered onto the ground when the pod stops mid swing (see UpdateStopping)
+const STOP_TOUCHDOWN_SPEED = 100.0
+
+// End effectors less than LIFT_TOLERANCE mm above the stride path are considered to be on the ground
+const LIFT_TOLERANCE = 1e-3
+
+// GaitPattern is the binary (1 == swing phase, 0 == stance phase) gait table used by older versions.
+// Each row is a leg, and each column is an equal part of the gait cycle.
 type GaitPattern [][]int
 
 type Gait struct {
-	Pattern                 *GaitPattern
-	StanceReturnSpeedFactor float64
-	Name                    string
-	NumIndicesInPattern     int
+	Name string
+	// Fraction of the gait cycle each leg spends in the stance phase (0 < DutyFactor < 1)
+	DutyFactor float64
+	// Phase (0-1) in the gait cycle where each leg lifts off and starts the swing phase
+	PhaseOffsets []float64
+	// Maximum height of the swing phase arc (Z_LIFT is used if 0)
+	LiftHeight float64 `json:",omitempty"`
+	// Trajectory of the end effectors in the swing phase (a sine arc if nil)
+	SwingProfile *SwingProfile `json:",omitempty"`
+	// Swing profiles for individual legs (nil entries use the swing profile of the gait)
+	LegSwingProfiles []*SwingProfile `json:",omitempty"`
+	// Number of ticks in the swing phase (INTERPOLATION_STEPS if 0)
+	InterpolationSteps int `json:",omitempty"`
+	// Rate (ticks per second) of the simulation clock the gait is timed for. Set by the pod (see SetGait)
+	TickRate float64 `json:"-"`
+	// Gait pattern saved by older versions. Converted to phase offsets when loaded
+	Pattern             *GaitPattern `json:"Pattern,omitempty"`
+	NumIndicesInPattern int          `json:"NumIndicesInPattern,omitempty"`
 }
 
-func NewHeptapodGait(GaitType GaitType) (*Gait, error) {
-	if GaitType != WAVE {
-		return nil, fmt.Errorf("Nope. Not doing that. Give it time and you'll figure out why (...)")
+// NewPhaseGait returns a gait defined by a duty factor and the phase offset for each leg
+func NewPhaseGait(name string, dutyFactor float64, phaseOffsets []float64) (*Gait, error) {
+	g := &Gait{Name: name, DutyFactor: dutyFactor, PhaseOffsets: phaseOffsets}
+	return g, g.Validate(len(phaseOffsets))
+}
+
+// NewGaitFromPattern converts a binary gait pattern (as used by older versions) to a phase gait.
+// Each leg must swing in one continuous run of columns (which may wrap around the end of the pattern),
+// and the runs must have the same length for all legs.
+func NewGaitFromPattern(name string, pattern GaitPattern, NumIndicesInPattern int, NumLegs int) (*Gait, error) {
+	if NumIndicesInPattern < 2 || len(pattern) < NumLegs {
+		return nil, fmt.Errorf("the gait pattern must have a row for each leg and at least two columns")
 	}
-	p := make(GaitPattern, 49)
 
-	copy(p, [][]int{
-		{1, 0, 0, 0, 0, 0, 0}, // 1 == swing phase, 0 == stance phase
-		{0, 1, 0, 0, 0, 0, 0},
-		{0, 0, 1, 0, 0, 0, 0},
-		{0, 0, 0, 1, 0, 0, 0},
-		{0, 0, 0, 0, 1, 0, 0},
-		{0, 0, 0, 0, 0, 1, 0},
-		{0, 0, 0, 0, 0, 0, 1}})
-	return &Gait{
-		Pattern:                 &p,
-		StanceReturnSpeedFactor: 0.16,
-		Name:                    "Wave gait",
-		NumIndicesInPattern:     7,
-	}, nil
+	swing := func(l int, step int) bool {
+		step = (step + NumIndicesInPattern) % NumIndicesInPattern
+		return step < len(pattern[l]) && pattern[l][step] == 1
+	}
 
+	offsets := make([]float64, NumLegs)
+	swingLength := 0
+	for l := 0; l < NumLegs; l++ {
+		start := -1
+		length := 0
+		for step := 0; step < NumIndicesInPattern; step++ {
+			if swing(l, step) {
+				length++
+				if !swing(l, step-1) {
+					if start >= 0 {
+						return nil, fmt.Errorf("leg %d swings more than once in the gait pattern", l)
+					}
+					start = step
+				}
+			}
+		}
+		if length == 0 {
+			return nil, fmt.Errorf("leg %d never swings in the gait pattern", l)
+		}
+		if start < 0 {
+			return nil, fmt.Errorf("leg %d never touches the ground in the gait pattern", l)
+		}
+		if l > 0 && length != swingLength {
+			return nil, fmt.Errorf("leg %d swings for %d columns. Leg 0 swings for %d columns", l, length, swingLength)
+		}
+		swingLength = length
+		offsets[l] = float64(start) / float64(NumIndicesInPattern)
+	}
+
+	return NewPhaseGait(name, float64(NumIndicesInPattern-swingLength)/float64(NumIndicesInPattern), offsets)
 }
 
-func NewPentapodGait(GaitType GaitType) (*Gait, error) {
-	if GaitType != WAVE {
-		return nil, fmt.Errorf("Nope. Not doing that. Give it time and you'll figure out why (...)")
+// Validate returns an error if the gait is unusable for a pod with a given number of legs
+func (g *Gait) Validate(NumLegs int) error {
+	if g.DutyFactor <= 0 || g.DutyFactor >= 1 {
+		return fmt.Errorf("the duty factor must be between 0 and 1 (was %2.2f)", g.DutyFactor)
+	}
+	if len(g.PhaseOffsets) != NumLegs {
+		return fmt.Errorf("the gait has phase offsets for %d legs. The pod has %d legs", len(g.PhaseOffsets), NumLegs)
+	}
+	if g.InterpolationSteps != 0 {
+		err := validateInterpolationSteps(g.InterpolationSteps)
+		if err != nil {
+			return err
+		}
+	}
+	if g.LiftHeight < 0 {
+		return fmt.Errorf("the lift height can not be negative (was %2.2f)", g.LiftHeight)
+	}
+	for l, offset := range g.PhaseOffsets {
+		if offset < 0 || offset >= 1 {
+			return fmt.Errorf("the phase offset for leg %d must be in the range [0, 1) (was %2.2f)", l, offset)
+		}
+	}
+	if g.SwingProfile != nil {
+		err := g.SwingProfile.Validate()
+		if err != nil {
+			return err
+		}
 	}
+	if len(g.LegSwingProfiles) != 0 && len(g.LegSwingProfiles) != NumLegs {
+		return fmt.Errorf("the gait has swing profiles for %d legs. The pod has %d legs", len(g.LegSwingProfiles), NumLegs)
+	}
+	for l, profile := range g.LegSwingProfiles {
+		if profile == nil {
+			continue
+		}
+		err := profile.Validate()
+		if err != nil {
+			return fmt.Errorf("leg %d: %w", l, err)
+		}
+	}
+	return nil
+}
+
+// LegPhase returns where in its own step cycle (0-1) a leg is for a given phase of the gait cycle.
+// The leg is in the swing phase from 0 to 1 - DutyFactor and in the stance phase for the rest of the cycle.
+func (g *Gait) LegPhase(legIndex int, cyclePhase float64) float64 {
+	phase := math.Mod(cyclePhase-g.PhaseOffsets[legIndex], 1)
+	if phase < 0 {
+		phase += 1
+	}
+	return phase
+}
 
-	p := make(GaitPattern, 25)
+// IsSwing returns true if a leg phase (see LegPhase) is in the swing phase
+func (g *Gait) IsSwing(legPhase float64) bool {
+	return legPhase < 1-g.DutyFactor
+}
 
-	copy(p, [][]int{
-		{1, 0, 0, 0, 0}, // 1 == swing phase, 0 == stance phase
-		{0, 1, 0, 0, 0},
-		{0, 0, 1, 0, 0},
-		{0, 0, 0, 1, 0},
-		{0, 0, 0, 0, 1}})
-	return &Gait{
-		Pattern:                 &p,
-		StanceReturnSpeedFactor: 0.2,
-		Name:                    "Wave gait",
-		NumIndicesInPattern:     5,
-	}, nil
+// PhaseStep returns the change in gait cycle phase for every tick. The swing phase
+// lasts for InterpolationSteps ticks.
+func (g *Gait) PhaseStep() float64 {
+	return (1 - g.DutyFactor) / float64(g.Steps())
 }
 
-func NewHexapodGait(GaitType GaitType) (*Gait, error) {
-	p := make(GaitPattern, 36)
-
-	if GaitType == WAVE {
-		copy(p, [][]int{{0, 0, 1, 0, 0, 0}, // 1 == swing phase, 0 == stance phase
-			{0, 1, 0, 0, 0, 0},
-			{1, 0, 0, 0, 0, 0},
-			{0, 0, 0, 0, 0, 1},
-			{0, 0, 0, 0, 1, 0},
-			{0, 0, 0, 1, 0, 0}})
-
-		return &Gait{
-			Pattern:                 &p,
-			StanceReturnSpeedFactor: 0.19, // Yeah, there is a rounding error somewhere. Sue me.
-			Name:                    "Wave gait",
-			NumIndicesInPattern:     6,
-		}, nil
-	} else if GaitType == RIPPLE {
-		copy(p, [][]int{{0, 0, 1, 0, 0, 1}, // 1 == swing phase, 0 == stance phase
-			{1, 0, 0},
-			{0, 1, 0},
-			{0, 0, 1},
-			{1, 0, 0},
-			{0, 1, 0}})
-
-		return &Gait{
-			Pattern:                 &p,
-			StanceReturnSpeedFactor: 0.4,
-			Name:                    "Ripple gait",
-			NumIndicesInPattern:     3,
-		}, nil
-	}
-
-	// default to TRIPOD
-	copy(p, [][]int{
-		{0, 1}, // 1 == swing phase, 0 == stance phase
-		{1, 0},
-		{0, 1},
-		{1, 0},
-		{0, 1},
-		{1, 0},
-		{0, 1},
-		{1, 0}})
-	return &Gait{
-		Pattern:                 &p,
-		StanceReturnSpeedFactor: 1,
-		Name:                    "Tripod gait",
-		NumIndicesInPattern:     2,
-	}, nil
+// Steps returns the number of ticks in the swing phase
+func (g *Gait) Steps() int {
+	if g.InterpolationSteps > 0 {
+		return g.InterpolationSteps
+	}
+	return INTERPOLATION_STEPS
 }
 
-func NewGait(NumLegs int, GaitType GaitType) (*Gait, error) {
+// Lift returns the maximum height of the swing phase arc for the gait
+func (g *Gait) Lift() float64 {
+	if g.LiftHeight > 0 {
+		return g.LiftHeight
+	}
+	return Z_LIFT
+}
+
+// LegSwingProfile returns the swing profile used by a leg, with the lift height of the gait
+// filled in if the profile has no height of its own
+func (g *Gait) LegSwingProfile(legIndex int) *SwingProfile {
+	profile := SwingProfile{Type: SINE}
+	if g.SwingProfile != nil {
+		profile = *g.SwingProfile
+	}
+	if legIndex < len(g.LegSwingProfiles) && g.LegSwingProfiles[legIndex] != nil {
+		profile = *g.LegSwingProfiles[legIndex]
+	}
+	if profile.Height == 0 {
+		profile.Height = g.Lift()
+	}
+	return &profile
+}
+
+// SwingTicks returns the number of ticks in the swing phase
+func (g *Gait) SwingTicks() float64 {
+	return (1 - g.DutyFactor) / g.PhaseStep()
+}
 
-	switch NumLegs {
-	case 6:
-		return NewHexapodGait(GaitType)
-	case 5:
-		return NewPentapodGait(GaitType)
+// Dt returns the duration (seconds) of a tick (see Clock)
+func (g *Gait) Dt() float64 {
+	if g.TickRate > 0 {
+		return 1 / g.TickRate
 	}
+	return 1 / DEFAULT_TICK_RATE
+}
+
+// SwingDuration returns the duration (seconds) of the swing phase
+func (g *Gait) SwingDuration() float64 {
+	return g.SwingTicks() * g.Dt()
+}
+
+// StanceDuration returns the duration (seconds) of the stance phase
+func (g *Gait) StanceDuration() float64 {
+	return g.DutyFactor / g.PhaseStep() * g.Dt()
+}
+
+// CycleTicks returns the number of ticks in a full gait cycle
+func (g *Gait) CycleTicks() int {
+	return int(math.Ceil(1/g.PhaseStep() - 1e-9))
+}
+
+// LegOrdering describes how the legs are placed on the body. Both sides are ordered front to back.
+type LegOrdering struct {
+	Left  []int
+	Right []int
+}
+
+// NewLegOrdering returns the default leg ordering for a pod with a given number of legs.
+// The legs are assumed to be numbered counter clockwise around the body starting with the
+// left front leg (as in all example pods), so the first half of the legs are on the left side.
+// With an odd number of legs, the left side has one leg more than the right side.
+func NewLegOrdering(NumLegs int) LegOrdering {
+	var o LegOrdering
+	left := (NumLegs + 1) / 2
+	for i := 0; i < left; i++ {
+		o.Left = append(o.Left, i)
+	}
+	for i := NumLegs - 1; i >= left; i-- {
+		o.Right = append(o.Right, i)
+	}
+	return o
+}
+
+// NumLegs returns the number of legs in the ordering
+func (o LegOrdering) NumLegs() int {
+	return len(o.Left) + len(o.Right)
+}
 
-	return nil, fmt.Errorf("missing gait definition for pod with %d legs. Please update gaits.go.", NumLegs)
+// NewGaitFromOrdering generates a gait for any number of legs
+func NewGaitFromOrdering(ordering LegOrdering, GaitType GaitType) (*Gait, error) {
+	numLegs := ordering.NumLegs()
+	if numLegs < 2 {
+		return nil, fmt.Errorf("a gait needs at least two legs")
+	}
+
+	sideLength := max(len(ordering.Left), len(ordering.Right))
+
+	// column returns the pattern index where the leg with index i (counting from the
+	// rear) on a given side (0 == left, 1 == right) is in the swing phase
+	var numIndices int
+	var column func(side int, i int) int
+
+	switch GaitType {
+	case WAVE:
+		numIndices = numLegs
+		column = func(side int, i int) int {
+			return side*len(ordering.Left) + i
+		}
+	case RIPPLE:
+		numIndices = sideLength
+		column = func(side int, i int) int {
+			return (i + side*(numIndices/2)) % numIndices
+		}
+	case TRIPOD:
+		numIndices = 2
+		column = func(side int, i int) int {
+			return (i + side) % 2
+		}
+	case METACHRONAL:
+		numIndices = max(2, min(METACHRONAL_WAVE_LENGTH, sideLength))
+		column = func(side int, i int) int {
+			return (i + side*(numIndices/2)) % numIndices
+		}
+	default:
+		return nil, fmt.Errorf("unknown gait type %d", GaitType)
+	}
+
+	offsets := make([]float64, numLegs)
+	assigned := make([]bool, numLegs)
+	for _, leg := range append(append([]int{}, ordering.Left...), ordering.Right...) {
+		if leg < 0 || leg >= numLegs || assigned[leg] {
+			return nil, fmt.Errorf("the leg ordering must contain each leg (0-%d) exactly once", numLegs-1)
+		}
+		assigned[leg] = true
+	}
+	used := make([]bool, numIndices)
+
+	for side, legs := range [][]int{ordering.Left, ordering.Right} {
+		for i := range legs {
+			// The legs are ordered front to back, while the waves move from the rear to the front
+			leg := legs[len(legs)-1-i]
+			used[column(side, i)] = true
+			offsets[leg] = float64(column(side, i)) / float64(numIndices)
+		}
+	}
+
+	// An empty part of the gait cycle would leave all legs on the ground without moving forward
+	for step := range used {
+		if !used[step] {
+			return nil, fmt.Errorf("no leg is in the swing phase in part %d of the %s gait cycle", step, GaitType)
+		}
+	}
+
+	return NewPhaseGait(fmt.Sprintf("%s gait", GaitType), float64(numIndices-1)/float64(numIndices), offsets)
+}
+
+func NewHeptapodGait(GaitType GaitType) (*Gait, error) {
+	return NewGait(7, GaitType)
+}
+
+func NewPentapodGait(GaitType GaitType) (*Gait, error) {
+	return NewGait(5, GaitType)
+}
+
+func NewHexapodGait(GaitType GaitType) (*Gait, error) {
+	return NewGait(6, GaitType)
+}
+
+// NewGait generates a gait for a pod using the default leg ordering
+func NewGait(NumLegs int, GaitType GaitType) (*Gait, error) {
+	return NewGaitFromOrdering(NewLegOrdering(NumLegs), GaitType)
 }
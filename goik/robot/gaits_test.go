@@ -0,0 +1,224 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNewLegOrdering(t *testing.T) {
+	tests := []struct {
+		numLegs int
+		want    LegOrdering
+	}{
+		{5, LegOrdering{Left: []int{0, 1, 2}, Right: []int{4, 3}}},
+		{6, LegOrdering{Left: []int{0, 1, 2}, Right: []int{5, 4, 3}}},
+		{8, LegOrdering{Left: []int{0, 1, 2, 3}, Right: []int{7, 6, 5, 4}}},
+	}
+
+	for _, test := range tests {
+		if o := NewLegOrdering(test.numLegs); !reflect.DeepEqual(o, test.want) || o.NumLegs() != test.numLegs {
+			t.Errorf("%d legs: ordering %+v, want %+v", test.numLegs, o, test.want)
+		}
+	}
+}
+
+// The generated gaits divide the gait cycle into equal parts, and every part has a leg in the swing phase
+func TestNewGaitFromOrdering(t *testing.T) {
+	for numLegs := 5; numLegs <= 8; numLegs++ {
+		side := (numLegs + 1) / 2
+		parts := map[GaitType]int{
+			WAVE:        numLegs,
+			RIPPLE:      side,
+			TRIPOD:      2,
+			METACHRONAL: max(2, min(METACHRONAL_WAVE_LENGTH, side)),
+		}
+
+		for gaitType, n := range parts {
+			gait, err := NewGait(numLegs, gaitType)
+			if err != nil {
+				t.Errorf("%d legs, %s: %v", numLegs, gaitType, err)
+				continue
+			}
+			if want := float64(n-1) / float64(n); math.Abs(gait.DutyFactor-want) > 1e-9 {
+				t.Errorf("%d legs, %s: duty factor %2.3f, want %2.3f", numLegs, gaitType, gait.DutyFactor, want)
+			}
+
+			used := make([]bool, n)
+			for l, offset := range gait.PhaseOffsets {
+				part := int(math.Round(offset * float64(n)))
+				if math.Abs(offset*float64(n)-float64(part)) > 1e-9 || part >= n {
+					t.Errorf("%d legs, %s: leg %d has phase offset %2.3f", numLegs, gaitType, l, offset)
+					continue
+				}
+				used[part] = true
+			}
+			for part := range used {
+				if !used[part] {
+					t.Errorf("%d legs, %s: no leg swings in part %d of %d", numLegs, gaitType, part, n)
+				}
+			}
+			if gaitType == WAVE {
+				// One leg at a time
+				seen := map[float64]bool{}
+				for _, offset := range gait.PhaseOffsets {
+					if seen[offset] {
+						t.Errorf("%d legs, wave: two legs swing together %v", numLegs, gait.PhaseOffsets)
+						break
+					}
+					seen[offset] = true
+				}
+			}
+		}
+	}
+}
+
+func TestGaitPhaseOffsets(t *testing.T) {
+	tests := []struct {
+		numLegs  int
+		gaitType GaitType
+		offsets  []float64
+	}{
+		// The waves move from the rear to the front, the left side first
+		{5, WAVE, []float64{0.4, 0.2, 0, 0.6, 0.8}},
+		{6, TRIPOD, []float64{0, 0.5, 0, 0.5, 0, 0.5}},
+		{6, RIPPLE, []float64{2.0 / 3, 1.0 / 3, 0, 1.0 / 3, 2.0 / 3, 0}},
+	}
+
+	for _, test := range tests {
+		gait, err := NewGait(test.numLegs, test.gaitType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for l := range test.offsets {
+			if math.Abs(gait.PhaseOffsets[l]-test.offsets[l]) > 1e-9 {
+				t.Errorf("%d legs, %s: phase offsets %v, want %v", test.numLegs, test.gaitType, gait.PhaseOffsets, test.offsets)
+				break
+			}
+		}
+	}
+}
+
+func TestInvalidLegOrdering(t *testing.T) {
+	tests := []struct {
+		name     string
+		ordering LegOrdering
+	}{
+		{"one leg", LegOrdering{Left: []int{0}}},
+		{"leg twice", LegOrdering{Left: []int{0, 1}, Right: []int{1, 2}}},
+		{"leg out of range", LegOrdering{Left: []int{0, 1}, Right: []int{2, 4}}},
+		{"negative leg", LegOrdering{Left: []int{0, -1}, Right: []int{2, 3}}},
+	}
+
+	for _, test := range tests {
+		if _, err := NewGaitFromOrdering(test.ordering, TRIPOD); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+	if _, err := NewGait(6, GaitType(7)); err == nil {
+		t.Error("no error for an unknown gait type")
+	}
+}
+
+func TestPhaseGait(t *testing.T) {
+	gait, err := NewPhaseGait("test", 0.75, []float64{0, 0.25, 0.5, 0.75})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		leg        int
+		cyclePhase float64
+		legPhase   float64
+		swing      bool
+	}{
+		{0, 0, 0, true},
+		{0, 0.2, 0.2, true},
+		{0, 0.25, 0.25, false},
+		{1, 0.2, 0.95, false},
+		{1, 0.3, 0.05, true},
+		{3, 0.5, 0.75, false},
+		{3, 1.8, 0.05, true},
+	}
+	for _, test := range tests {
+		phase := gait.LegPhase(test.leg, test.cyclePhase)
+		if math.Abs(phase-test.legPhase) > 1e-9 || gait.IsSwing(phase) != test.swing {
+			t.Errorf("leg %d at %2.2f: leg phase %2.2f (swing %t), want %2.2f (swing %t)",
+				test.leg, test.cyclePhase, phase, gait.IsSwing(phase), test.legPhase, test.swing)
+		}
+	}
+
+	// The swing phase takes INTERPOLATION_STEPS ticks, and the stance phase three times as long
+	if gait.CycleTicks() != 4*INTERPOLATION_STEPS {
+		t.Errorf("%d ticks per cycle, want %d", gait.CycleTicks(), 4*INTERPOLATION_STEPS)
+	}
+}
+
+func TestValidateGait(t *testing.T) {
+	tests := []struct {
+		name string
+		gait Gait
+	}{
+		{"duty factor 0", Gait{DutyFactor: 0, PhaseOffsets: []float64{0, 0.5}}},
+		{"duty factor 1", Gait{DutyFactor: 1, PhaseOffsets: []float64{0, 0.5}}},
+		{"missing leg", Gait{DutyFactor: 0.5, PhaseOffsets: []float64{0}}},
+		{"offset 1", Gait{DutyFactor: 0.5, PhaseOffsets: []float64{0, 1}}},
+		{"negative offset", Gait{DutyFactor: 0.5, PhaseOffsets: []float64{-0.5, 0}}},
+	}
+
+	for _, test := range tests {
+		if err := test.gait.Validate(2); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
+
+// Gait patterns saved by older versions convert to the same phase gaits
+func TestNewGaitFromPattern(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    GaitPattern
+		dutyFactor float64
+		offsets    []float64
+		valid      bool
+	}{
+		{"tripod", GaitPattern{{1, 0}, {0, 1}, {1, 0}, {0, 1}}, 0.5, []float64{0, 0.5, 0, 0.5}, true},
+		{"wrapping", GaitPattern{{1, 0, 0, 1}, {0, 1, 1, 0}}, 0.5, []float64{0.75, 0.25}, true},
+		{"never swings", GaitPattern{{1, 0}, {0, 0}}, 0, nil, false},
+		{"always swings", GaitPattern{{1, 0}, {1, 1}}, 0, nil, false},
+		{"swings twice", GaitPattern{{1, 0, 1, 0}, {0, 1, 0, 1}}, 0, nil, false},
+		{"different swing lengths", GaitPattern{{1, 0, 0}, {0, 1, 1}}, 0, nil, false},
+	}
+
+	for _, test := range tests {
+		gait, err := NewGaitFromPattern(test.name, test.pattern, len(test.pattern[0]), len(test.pattern))
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%s: no error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if gait.DutyFactor != test.dutyFactor || !reflect.DeepEqual(gait.PhaseOffsets, test.offsets) {
+			t.Errorf("%s: duty factor %2.2f and offsets %v, want %2.2f and %v",
+				test.name, gait.DutyFactor, gait.PhaseOffsets, test.dutyFactor, test.offsets)
+		}
+	}
+}
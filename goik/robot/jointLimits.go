@@ -0,0 +1,184 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"fmt"
+	"math"
+)
+
+// JointLimit defines the range of motion (in degrees) for a single joint.
+// A zero value (Min == Max == 0) means that the joint is not limited.
+type JointLimit struct {
+	Min float64 `json:"Min"`
+	Max float64 `json:"Max"`
+	// MaxSpeed is the maximum angular speed (degrees per second) of the joint.
+	// It is checked against the change of angle from one tick to the next. 0 means no speed limit.
+	MaxSpeed float64 `json:"MaxSpeed,omitempty"`
+}
+
+// IsDefined returns true if the joint has a range of motion defined
+func (j JointLimit) IsDefined() bool {
+	return j.Min != 0 || j.Max != 0
+}
+
+// Contains returns true if the angle is within the range of motion of the joint
+func (j JointLimit) Contains(angle float64) bool {
+	return !j.IsDefined() || (angle >= j.Min && angle <= j.Max)
+}
+
+// Clamp returns the angle limited to the range of motion of the joint
+func (j JointLimit) Clamp(angle float64) float64 {
+	if !j.IsDefined() {
+		return angle
+	}
+	return math.Max(j.Min, math.Min(j.Max, angle))
+}
+
+func (j JointLimit) String() string {
+	if !j.IsDefined() {
+		if j.MaxSpeed > 0 {
+			return fmt.Sprintf("unlimited range, max %2.2f deg/s", j.MaxSpeed)
+		}
+		return "unlimited"
+	}
+	if j.MaxSpeed == 0 {
+		return fmt.Sprintf("[%2.2f, %2.2f]", j.Min, j.Max)
+	}
+	return fmt.Sprintf("[%2.2f, %2.2f] max %2.2f deg/s", j.Min, j.Max, j.MaxSpeed)
+}
+
+// JointLimits contains the limits for all joints in a leg
+type JointLimits struct {
+	Coxa  JointLimit `json:"Coxa"`
+	Femur JointLimit `json:"Femur"`
+	Tibia JointLimit `json:"Tibia"`
+	// Limits for any additional joints (tarsus etc) ordered along the kinematic chain
+	Extra []JointLimit `json:"Extra,omitempty"`
+}
+
+// Get returns the limit for joint i in the kinematic chain
+func (j JointLimits) Get(i int) JointLimit {
+	switch i {
+	case 0:
+		return j.Coxa
+	case 1:
+		return j.Femur
+	case 2:
+		return j.Tibia
+	}
+	if i-3 < len(j.Extra) {
+		return j.Extra[i-3]
+	}
+	return JointLimit{}
+}
+
+// Len returns the number of joints (in kinematic chain order) up to and including the last joint with a limit.
+// Joints past the chain of a leg (tibia of a 2 joint leg etc) are then allowed, as long as they are not limited
+func (j JointLimits) Len() int {
+	for n := 3 + len(j.Extra); n > 0; n-- {
+		if limit := j.Get(n - 1); limit.IsDefined() || limit.MaxSpeed != 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// Set updates the limit for joint i in the kinematic chain
+func (j *JointLimits) Set(i int, limit JointLimit) {
+	switch i {
+	case 0:
+		j.Coxa = limit
+	case 1:
+		j.Femur = limit
+	case 2:
+		j.Tibia = limit
+	default:
+		extra := make([]JointLimit, max(len(j.Extra), i-2))
+		copy(extra, j.Extra)
+		extra[i-3] = limit
+		j.Extra = extra
+	}
+}
+
+// NewXL320JointLimits returns the limits for a leg built from XL-320 servos.
+// (300 degree range of motion centered around 0)
+func NewXL320JointLimits() JointLimits {
+	return JointLimits{
+		Coxa:  JointLimit{Min: -150, Max: 150},
+		Femur: JointLimit{Min: -150, Max: 150},
+		Tibia: JointLimit{Min: -150, Max: 150},
+	}
+}
+
+// JointLimitError is returned when a joint angle (or a change of angle)
+// is outside the limits defined for the joint
+type JointLimitError struct {
+	Leg   int
+	Joint string
+	// The angle outside the range of motion, or the speed (deg/s) above the speed limit
+	Angle float64
+	Limit JointLimit
+	// True if it is the speed limit that has been exceeded
+	Speed bool
+}
+
+func (e *JointLimitError) Error() string {
+	if e.Speed {
+		return fmt.Sprintf("leg %d: %s moves at %2.2f deg/s. The joint is limited to %2.2f deg/s", e.Leg, e.Joint, e.Angle, e.Limit.MaxSpeed)
+	}
+	return fmt.Sprintf("leg %d: %s angle %2.2f is outside the joint limits %s", e.Leg, e.Joint, e.Angle, e.Limit.String())
+}
+
+// Check returns an error describing the first joint that is outside its limits
+func (j JointLimits) Check(legIndex int, angles ServoAngles) error {
+	for i := 0; i < angles.Len(); i++ {
+		if !j.Get(i).Contains(angles.Get(i)) {
+			return &JointLimitError{Leg: legIndex, Joint: JointName(i), Angle: angles.Get(i), Limit: j.Get(i)}
+		}
+	}
+	return nil
+}
+
+// HasSpeedLimit returns true if any joint has a speed limit
+func (j JointLimits) HasSpeedLimit() bool {
+	for i := 0; i < 3+len(j.Extra); i++ {
+		if j.Get(i).MaxSpeed > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckSpeed returns an error if any joint moves faster than its speed limit
+// when moving from one set of angles to the next in a tick lasting dt seconds
+func (j JointLimits) CheckSpeed(legIndex int, from ServoAngles, to ServoAngles, dt float64) error {
+	for i := 0; i < max(from.Len(), to.Len()); i++ {
+		speed := math.Abs(to.Get(i)-from.Get(i)) / dt
+		if j.Get(i).MaxSpeed > 0 && speed > j.Get(i).MaxSpeed {
+			return &JointLimitError{Leg: legIndex, Joint: JointName(i), Angle: speed, Limit: j.Get(i), Speed: true}
+		}
+	}
+	return nil
+}
+
+// Clamp limits all angles to the range of motion of the corresponding joint
+func (j JointLimits) Clamp(angles ServoAngles) ServoAngles {
+	var clamped ServoAngles
+	for i := 0; i < angles.Len(); i++ {
+		clamped.Set(i, j.Get(i).Clamp(angles.Get(i)))
+	}
+	return clamped
+}
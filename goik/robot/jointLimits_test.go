@@ -0,0 +1,203 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestJointLimit(t *testing.T) {
+	limit := JointLimit{Min: -90, Max: 45}
+	tests := []struct {
+		limit    JointLimit
+		angle    float64
+		contains bool
+		clamped  float64
+	}{
+		{limit, 0, true, 0},
+		{limit, -90, true, -90},
+		{limit, 45, true, 45},
+		{limit, -91, false, -90},
+		{limit, 100, false, 45},
+		{JointLimit{}, 500, true, 500},
+		{JointLimit{MaxSpeed: 100}, -500, true, -500},
+	}
+
+	for _, test := range tests {
+		if test.limit.Contains(test.angle) != test.contains {
+			t.Errorf("%s contains %2.2f: %t, want %t", test.limit, test.angle, !test.contains, test.contains)
+		}
+		if clamped := test.limit.Clamp(test.angle); clamped != test.clamped {
+			t.Errorf("%s clamps %2.2f to %2.2f, want %2.2f", test.limit, test.angle, clamped, test.clamped)
+		}
+	}
+}
+
+// Check reports the leg and the first joint outside its limits
+func TestJointLimitsCheck(t *testing.T) {
+	limits := NewXL320JointLimits()
+	tests := []struct {
+		angles ServoAngles
+		joint  string
+		angle  float64
+	}{
+		{ServoAngles{Coxa: 10, Femur: -50, Tibia: 100}, "", 0},
+		{ServoAngles{Coxa: 160, Femur: -50, Tibia: 100}, "coxa", 160},
+		{ServoAngles{Coxa: 10, Femur: -170, Tibia: 100}, "femur", -170},
+		{ServoAngles{Coxa: 10, Femur: -50, Tibia: 151}, "tibia", 151},
+		{ServoAngles{Coxa: 10, Femur: -170, Tibia: 151}, "femur", -170},
+	}
+
+	for _, test := range tests {
+		err := limits.Check(3, test.angles)
+		if test.joint == "" {
+			if err != nil {
+				t.Errorf("%+v: %v", test.angles, err)
+			}
+			continue
+		}
+		var limitErr *JointLimitError
+		if !errors.As(err, &limitErr) {
+			t.Errorf("%+v: error %v, want a JointLimitError", test.angles, err)
+			continue
+		}
+		if limitErr.Leg != 3 || limitErr.Joint != test.joint || limitErr.Angle != test.angle {
+			t.Errorf("%+v: %v, want leg 3 %s at %2.2f", test.angles, err, test.joint, test.angle)
+		}
+	}
+
+	clamped := limits.Clamp(ServoAngles{Coxa: 160, Femur: -170, Tibia: 100})
+	if clamped.Coxa != 150 || clamped.Femur != -150 || clamped.Tibia != 100 {
+		t.Errorf("clamped to %+v", clamped)
+	}
+}
+
+// A stride the joints are unable to follow is rejected, and the pod is left untouched
+func TestStrideRejectedByJointLimits(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	limits := NewXL320JointLimits()
+	limits.Coxa = JointLimit{Min: -5, Max: 5}
+	if err := p.SetJointLimits(4, limits); err != nil {
+		t.Fatal(err)
+	}
+	before := make([]IntermediateEffectorCoordinates, len(p.Legs))
+	for i, l := range p.Legs {
+		before[i] = l.IntermediateEffectorCoordinates
+	}
+
+	for _, set := range []func() error{
+		func() error { return p.SetStrideVector(1, 40, 0) },
+		func() error { return p.SetRotation(1, 30) },
+	} {
+		err := set()
+		var limitErr *JointLimitError
+		if !errors.As(err, &limitErr) || limitErr.Leg != 4 || limitErr.Joint != "coxa" {
+			t.Errorf("error %v, want a coxa limit error for leg 4", err)
+		}
+		if p.HasDefinedStride() {
+			t.Error("the stride was defined")
+		}
+		for i, l := range p.Legs {
+			if len(l.IntermediateEffectorCoordinates) != len(before[i]) {
+				t.Errorf("leg %d: the stride path changed", i)
+			}
+		}
+	}
+}
+
+// The pod is grounded only if every leg reaches the height
+func TestGround(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	height := p.Legs[0].Effector().Z + 10
+	if err := p.Ground(height); err != nil {
+		t.Fatal(err)
+	}
+	for i, l := range p.Legs {
+		if l.Effector().Z-height > 1e-6 || height-l.Effector().Z > 1e-6 {
+			t.Errorf("leg %d at Z %2.2f, want %2.2f", i, l.Effector().Z, height)
+		}
+	}
+
+	feet := p.GetEndEffectorPositions()
+	if err := p.Ground(height + 500); err == nil {
+		t.Fatal("no error grounding 500 mm below the body")
+	}
+	for i, l := range p.Legs {
+		if !closeTo(l.Effector(), feet[i], 1e-9) {
+			t.Errorf("leg %d moved from %+v to %+v", i, feet[i], l.Effector())
+		}
+	}
+}
+
+// Speed limits are in degrees per second, so the same change of angle is faster in a shorter tick
+func TestJointLimitsCheckSpeed(t *testing.T) {
+	limits := JointLimits{Femur: JointLimit{Min: -150, Max: 150, MaxSpeed: 300}}
+	from := ServoAngles{Coxa: 0, Femur: 0, Tibia: 0}
+	tests := []struct {
+		to    ServoAngles
+		dt    float64
+		valid bool
+	}{
+		{ServoAngles{Femur: 6}, 0.02, true},
+		{ServoAngles{Femur: -6}, 0.02, true},
+		{ServoAngles{Femur: 7}, 0.02, false},
+		{ServoAngles{Femur: 7}, 0.05, true},
+		{ServoAngles{Femur: 3}, 0.005, false},
+		// Joints without a speed limit move at any speed
+		{ServoAngles{Coxa: 90, Tibia: -90}, 0.001, true},
+	}
+
+	for _, test := range tests {
+		err := limits.CheckSpeed(1, from, test.to, test.dt)
+		if test.valid && err != nil {
+			t.Errorf("%+v in %2.3f s: %v", test.to, test.dt, err)
+		}
+		if test.valid {
+			continue
+		}
+		var limitErr *JointLimitError
+		if !errors.As(err, &limitErr) || !limitErr.Speed || limitErr.Joint != "femur" {
+			t.Errorf("%+v in %2.3f s: error %v, want a femur speed error", test.to, test.dt, err)
+			continue
+		}
+		if speed := math.Abs(test.to.Femur) / test.dt; math.Abs(limitErr.Angle-speed) > 1e-9 {
+			t.Errorf("%+v in %2.3f s: speed %2.2f deg/s, want %2.2f deg/s", test.to, test.dt, limitErr.Angle, speed)
+		}
+	}
+}
+
+// A stride too fast for a joint at one tick rate is possible at a lower tick rate
+func TestStrideRejectedBySpeedLimit(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	limits := NewXL320JointLimits()
+	limits.Coxa.MaxSpeed = 20
+	for l := range p.Legs {
+		if err := p.SetJointLimits(l, limits); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := p.SetStrideVector(1, 20, 0); err == nil {
+		t.Fatalf("no error walking at %2.2f Hz", p.Clock.Rate)
+	}
+	if err := p.SetTickRate(p.Clock.Rate / 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetStrideVector(1, 20, 0); err != nil {
+		t.Errorf("%2.2f Hz: %v", p.Clock.Rate, err)
+	}
+}
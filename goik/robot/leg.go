@@ -20,10 +20,11 @@ import (
 	"gonum.org/v1/gonum/mat"
 )
 
-// The number of joints in a leg (Coxa, Femur, Tibia, End effector)
+// The number of joints in a standard leg (Coxa, Femur, Tibia, End effector)
+// Legs described by a custom kinematic chain may have any number of joints
 const NUM_JOINTS = 4
 
-// Offsets in leg joint array
+// Offsets in leg joint array (The end effector offset is only valid for standard legs. Use Leg.Effector())
 const COXA_ORIGIN_INDEX = 0
 const FEMUR_ORIGIN_INDEX = 1
 const TIBIA_ORIGIN_INDEX = 2
@@ -32,14 +33,11 @@ const EFFECTOR_ORIGIN_INDEX = 3
 // Instead of just attempting to move a servo from one location directly to another
 // we create a series of intermediate steps. This also allows us to calculate an arc
 // and also lift the end effector when the target position is in the same plane as
-// the starting position
-// type IntermediateAngles []ServoAngles
+// the starting position. The number of intermediate steps is chosen when the motion is
+// planned (see Pod.SetInterpolationSteps)
+type IntermediateAngles []ServoAngles
 
-type IntermediateAngles struct {
-	JointAngle [NUM_JOINTS - 1][INTERPOLATION_STEPS]float64
-}
-
-type IntermediateEffectorCoordinates [INTERPOLATION_STEPS]Coordinate
+type IntermediateEffectorCoordinates []Coordinate
 
 // SegmentLengths defines the lengths of each segment in the robot leg
 // The length is calculated from the origin of one reference frame to
@@ -50,6 +48,31 @@ type SegmentLengths struct {
 	Tibia float64 `json:"T"`
 }
 
+// KneeConfiguration selects which of the two solutions to the leg IK equations a leg is using
+type KneeConfiguration int
+
+const (
+	// The knee (femur/tibia joint) points away from the ground (tibia angle >= 0)
+	KneeUp KneeConfiguration = 0
+	// The knee points towards the ground (tibia angle <= 0)
+	KneeDown KneeConfiguration = 1
+)
+
+func (k KneeConfiguration) String() string {
+	if k == KneeDown {
+		return "down"
+	}
+	return "up"
+}
+
+// KneeConfigurationOf returns the knee configuration matching a set of servo angles
+func KneeConfigurationOf(angles ServoAngles) KneeConfiguration {
+	if angles.Tibia < 0 {
+		return KneeDown
+	}
+	return KneeUp
+}
+
 type Leg struct {
 	// Leg index is displayed in the simualtor views and
 	// is also used to calculate the servo ID representing
@@ -64,14 +87,26 @@ type Leg struct {
 	// the positioning of the Coxa reference frame origin in
 	// the robot body base reference frame.
 	OffsetTransformationMatrix *mat.Dense
+	// BodyPose defines the rotation and translation of the robot body
+	// in relation to the base reference frame. (All legs of a pod share
+	// the same body pose)
+	BodyPose BodyPose
 	// All robot legs are not necessarily created equal
 	// Most hexapods have identical leg topologies, but it
 	// never hurts to prepare for other form factors :)
 	SegmentLengths SegmentLengths
+	// Chain describes the leg as a chain of Denavit-Hartenberg links (one link per servo).
+	// Standard legs use a chain derived from the segment lengths
+	Chain DHChain
+	// Knee selects the IK solution (knee up or knee down) used for the leg
+	Knee KneeConfiguration
+	// JointLimits defines the range of motion for each joint. The IK
+	// solver rejects any solution outside these limits
+	JointLimits JointLimits
 	// The Joints array contain the location of the reference
 	// frame origin for each joint in the base reference frame
-	// coordinate system.
-	Joints [NUM_JOINTS]Coordinate
+	// coordinate system. The last element is the end effector.
+	Joints []Coordinate
 	// EffectorTarget is the target location for the leg's end effector
 	// when it is moving.
 	EffectorTarget Coordinate
@@ -79,18 +114,37 @@ type Leg struct {
 	// for performing a full step
 	IntermediateAngles IntermediateAngles
 	// Intermediate effector coordinates the set of target locations
-	// for the end effector required for performing a full step
-	// These do not necessarily describe a straigth line
+	// for the end effector (in the base reference frame) required for
+	// performing a full step. These do not necessarily describe a straigth line.
+	// The swing and stance phases solve the IK equations for these targets
+	// against the current body pose, so the body can be posed while walking
 	IntermediateEffectorCoordinates IntermediateEffectorCoordinates
 	// ServoAngles represent the angles for the current state of the leg
 	// (moving or stationary)
 	ServoAngles ServoAngles
-	// Swing interpolation index is the current index in the interpolation
-	// table for the leg while it is in the swing phase
-	swingInterpolationIndex int
-	// Stance interpolation index is the current index in the interpolation
-	// table for the leg while it is in the stance phase
-	stanceInterpolationIndex float64
+	// Current position (0-1) of the end effector along the stride path
+	// (the intermediate effector coordinates)
+	pathPosition float64
+	// True if the leg was in the swing phase at the previous tick
+	isSwinging bool
+	// True if the leg followed the gait at the previous tick
+	isInStep bool
+	// Phase left of the swing or stance phase at the previous tick
+	phaseRemaining float64
+	// Distance (mm) from the stride path to the end effector after the stride path has been replaced
+	// while walking. The offset shrinks to zero over the rest of the current phase
+	pathOffset Coordinate
+	// Progress (0-1) along the swing phase arc, the height of the arc (relative to the swing profile)
+	// and the progress per tick. A leg that is still in the air when the stance phase starts completes
+	// the arc before landing
+	liftProgress float64
+	liftScale    float64
+	liftRate     float64
+	// Swing profile of the current arc, and the duration (seconds) of the swing phase it was planned for
+	swingProfile  *SwingProfile
+	swingDuration float64
+	// Current height (mm) of the end effector above the stride path
+	lift float64
 	// NeutralEffectorCoordinate defines the end effector's coordinate in
 	// the base reference frame when the leg is in a neutral / rest position
 	NeutralEffectorCoordinate Coordinate
@@ -119,100 +173,250 @@ func (l *Leg) GetJointOrigin(H *mat.Dense) Coordinate {
 // (center of robot -> coxa origin -> femur origin -> tibia origin -> end effector)
 // We then create a homogeneous tranformation matrix containing the rotation matrix and
 // the displacement vector for each new reference frame in the chain.
+// (The links in the chain are described using Denavit-Hartenberg parameters, so that
+// legs with any number of joints can be represented)
 // By multiplying these matrices together, we can extract the coordinates for each frame (coxa, femur, tibia, end effector)
 // from the last column in the resulting 4x4 matrix. This gives us the data we need to represent the
 // robot in a 2/3D view.
 func (l *Leg) RecalculateForwardKinematics(angles ServoAngles) {
 	l.ServoAngles = angles
 
-	P_Femur := mat.NewDense(3, 3, []float64{1, 0, 0, 0, 1, 0, 0, 0, 1}) // Identity
-	P_Coxa := mat.NewDense(3, 3, []float64{1, 0, 0, 0, 0, -1, 0, 1, 0})
-	P_Tibia := mat.NewDense(3, 3, []float64{1, 0, 0, 0, 1, 0, 0, 0, 1}) // Identity
-
-	H_Coxa := HomogeneousTransformationMatrix(P_Coxa, angles.Coxa*math.Pi/180.0, l.SegmentLengths.Coxa)
-	H_Femur := HomogeneousTransformationMatrix(P_Femur, angles.Femur*math.Pi/180.0, l.SegmentLengths.Femur)
-	H_Tibia := HomogeneousTransformationMatrix(P_Tibia, angles.Tibia*math.Pi/180.0, l.SegmentLengths.Tibia)
+	frames := l.Frames()
 
-	var H0_1 mat.Dense
-	var H1_2 mat.Dense
-	var H2_3 mat.Dense
+	if len(l.Joints) != len(frames) {
+		l.Joints = make([]Coordinate, len(frames))
+	}
+	for i, H := range frames {
+		l.Joints[i] = l.GetJointOrigin(H)
+	}
+	l.EffectorTarget = l.Effector()
+}
 
-	H0_1.Mul(l.OffsetTransformationMatrix, H_Coxa)
-	H1_2.Mul(&H0_1, H_Femur)
-	H2_3.Mul(&H1_2, H_Tibia)
+// Frames returns the homogeneous transformation matrices (in the base reference frame)
+// for all reference frames in the leg (coxa origin, ..., end effector) using the current servo angles
+func (l *Leg) Frames() []*mat.Dense {
+	// The body pose is the first link in the chain (base reference frame -> body reference frame)
+	var H_Offset mat.Dense
+	H_Offset.Mul(l.BodyPose.TransformationMatrix(), l.OffsetTransformationMatrix)
 
-	l.Joints[COXA_ORIGIN_INDEX] = l.GetJointOrigin(l.OffsetTransformationMatrix)
-	l.Joints[FEMUR_ORIGIN_INDEX] = l.GetJointOrigin(&H0_1)
-	l.Joints[TIBIA_ORIGIN_INDEX] = l.GetJointOrigin(&H1_2)
-	l.Joints[EFFECTOR_ORIGIN_INDEX] = l.GetJointOrigin(&H2_3)
-	l.EffectorTarget = l.Joints[EFFECTOR_ORIGIN_INDEX]
+	return l.Chain.Frames(&H_Offset, l.ServoAngles.Values(len(l.Chain)))
 }
 
-// UpdateSwing moves the interpolation index to the next element
-// in the interpolation table for the swing phase until it reaches
-// the end. It then wraps around and starts from 0 again.
-// Return values:
-// 1: End of swing
-// 0: Interpolating swing
-func (l *Leg) UpdateSwing(direction Direction) bool {
-	angles := NewServoAngles(l.IntermediateAngles.JointAngle[0][l.swingInterpolationIndex], l.IntermediateAngles.JointAngle[1][l.swingInterpolationIndex], l.IntermediateAngles.JointAngle[2][l.swingInterpolationIndex])
-	l.RecalculateForwardKinematics(angles)
-
-	// We need to lift the legs in the swing phase, so we will modify Z target slightly
-	// when in the swing phase and then find a new solution where the leg is not touching the ground
-	phase_step := math.Pi / (INTERPOLATION_STEPS - 1)
-	z := l.Joints[EFFECTOR_ORIGIN_INDEX].Z
+// Effector returns the current location of the end effector in the base reference frame
+func (l *Leg) Effector() Coordinate {
+	return l.Joints[len(l.Joints)-1]
+}
 
-	// Phase should swing from 0 to pi
-	phase := phase_step * (float64(l.swingInterpolationIndex))
-	zNew := Z_LIFT * math.Sin(phase)
-	l.ServoAngles, _ = SolveEffectorIK(l, NewCoordinate(l.Joints[EFFECTOR_ORIGIN_INDEX].X, l.Joints[EFFECTOR_ORIGIN_INDEX].Y, z-zNew), l.debugChannel)
+// NumServos returns the number of servos (joints) in the leg
+func (l *Leg) NumServos() int {
+	return len(l.Chain)
+}
 
-	l.RecalculateForwardKinematics(l.ServoAngles)
+// Angles returns the current angle of each servo in the leg, ordered along the kinematic chain
+func (l *Leg) Angles() []float64 {
+	return l.ServoAngles.Values(len(l.Chain))
+}
 
-	l.swingInterpolationIndex += int(direction)
+// UpdateGait moves the end effector one tick along the stride path of a gait. legPhase is the phase (0-1) of the
+// leg in its own step cycle after the tick (see Gait.LegPhase), and phaseStep is the change in phase
+// for every tick (negative when walking in reverse).
+// The end effector is moved so that it reaches the end of the stride path at the end of the swing phase,
+// and the start of the stride path at the end of the stance phase. A leg that is somewhere else on
+// the path (when starting from the neutral stance, or after the gait has changed) catches up with the
+// gait over the rest of the current phase, moving at most GAIT_CATCH_UP_FACTOR times as fast as the
+// fastest phase of the gait.
+func (l *Leg) UpdateGait(gait *Gait, legPhase float64, phaseStep float64) {
+	dutyFactor := gait.DutyFactor
+	swingFraction := 1 - dutyFactor
+	swing := legPhase < swingFraction
+	step := math.Abs(phaseStep)
+
+	// The end of the stride path to reach at the end of the current phase, and how much of the phase is left
+	var end, remaining float64
+	length := dutyFactor
+	if swing {
+		length = swingFraction
+	}
+	switch {
+	case swing && phaseStep >= 0:
+		end, remaining = 1, swingFraction-legPhase
+	case swing:
+		end, remaining = 0, legPhase
+	case phaseStep >= 0:
+		end, remaining = 0, 1-legPhase
+	default:
+		end, remaining = 1, legPhase-swingFraction
+	}
 
-	// Each leg has a dedicated interpolation table for each joint.
-	if direction == Forward {
-		if l.swingInterpolationIndex > INTERPOLATION_STEPS-1 {
-			l.swingInterpolationIndex = 0
-			l.stanceInterpolationIndex = INTERPOLATION_STEPS - 1
-			return true
+	// Part of the tick may have been spent finishing the previous phase. (A leg that has not
+	// reached the end of the previous phase, because the gait changed, starts from where it is)
+	elapsed := math.Min(step, length-remaining)
+	position := l.pathPosition
+	if l.isInStep && l.isSwinging != swing && l.phaseRemaining <= step+1e-9 {
+		position = 1 - end
+	}
+	profile := gait.LegSwingProfile(l.Index)
+	if elapsed > 0 {
+		fraction := elapsed / (remaining + elapsed)
+		if swing {
+			// The swing profile decides how far along the path the end effector moves
+			before := profile.Advance(1 - (remaining+elapsed)/swingFraction)
+			after := profile.Advance(1 - remaining/swingFraction)
+			fraction = 1
+			if before < 1-1e-9 {
+				fraction = (after - before) / (1 - before)
+			}
 		}
-	} else if direction == Reverse {
-		if l.swingInterpolationIndex < 0 {
-			l.swingInterpolationIndex = INTERPOLATION_STEPS - 1
-			l.stanceInterpolationIndex = 0
-			return true
+		position += (end - position) * fraction
+	}
+	maxMove := GAIT_CATCH_UP_FACTOR * step / math.Min(swingFraction, dutyFactor)
+	if swing {
+		maxMove *= profile.maxAdvanceRate()
+	}
+	l.pathPosition += math.Max(-maxMove, math.Min(maxMove, position-l.pathPosition))
+	l.phaseRemaining = remaining
+	if elapsed > 0 {
+		shrink := remaining / (remaining + elapsed)
+		l.pathOffset = NewCoordinate(l.pathOffset.X*shrink, l.pathOffset.Y*shrink, l.pathOffset.Z*shrink)
+	}
+
+	if swing {
+		// A new swing phase. The arc is lower when the leg joins part way through the swing phase
+		if !l.isSwinging || !l.isInStep {
+			entry := math.Max(0, 1-(remaining+elapsed)/swingFraction)
+			l.liftProgress = 0
+			l.liftScale = 1 - entry
+			l.swingProfile = profile
+			l.swingDuration = gait.SwingDuration()
 		}
+		l.liftRate = math.Min(GAIT_CATCH_UP_FACTOR*step/swingFraction, (1-l.liftProgress)*elapsed/(remaining+elapsed))
+	}
+	// A leg still in the air in the stance phase completes the arc at the same rate
+	l.liftProgress = math.Min(1, l.liftProgress+l.liftRate)
+	l.lift = 0
+	if l.swingProfile != nil {
+		l.lift = l.liftScale * l.swingProfile.Lift(l.liftProgress, l.swingDuration)
 	}
-	return false
+	if l.lift < LIFT_TOLERANCE || l.liftProgress >= 1-1e-9 {
+		l.lift = 0
+	}
+
+	l.isSwinging = swing
+	l.isInStep = true
+
+	target := l.pathTarget()
+	l.moveEffector(NewCoordinate(target.X, target.Y, target.Z-l.lift))
 }
 
-// UpdateStance moves the interpolation index to the previous element
-// in the interpolation table for the stance phase until it reaches
-// the start. It then wraps around and starts from the end again.
-func (l *Leg) UpdateStance(direction Direction, stanceReturnFactor float64) {
-	angles := NewServoAngles(l.IntermediateAngles.JointAngle[0][int(l.stanceInterpolationIndex)], l.IntermediateAngles.JointAngle[1][int(l.stanceInterpolationIndex)], l.IntermediateAngles.JointAngle[2][int(l.stanceInterpolationIndex)])
-	l.ServoAngles = angles
-	l.RecalculateForwardKinematics(angles)
+// UpdateTouchdown lowers a lifted end effector straight down onto its stride path, moving at most speed (mm/s)
+// in a tick lasting dt seconds. The leg keeps its position on the path, and lifts off in a new swing phase arc
+// the next time it swings. Returns true once the end effector is on the ground
+func (l *Leg) UpdateTouchdown(speed float64, dt float64) bool {
+	l.isSwinging = false
+	l.liftProgress = 1
+	l.liftRate = 0
+	// The swing phase has not been completed (see UpdateGait)
+	l.phaseRemaining = 1
+	l.lift = math.Max(0, l.lift-speed*dt)
+	if l.lift < LIFT_TOLERANCE {
+		l.lift = 0
+	}
 
-	l.stanceInterpolationIndex -= float64(direction) * stanceReturnFactor
+	target := l.pathTarget()
+	l.moveEffector(NewCoordinate(target.X, target.Y, target.Z-l.lift))
+	return l.lift == 0
+}
+
+// pathTarget returns the location of the end effector on (or offset from) the stride path, before it is lifted
+func (l *Leg) pathTarget() Coordinate {
+	c := pathAt(l.IntermediateEffectorCoordinates, l.pathPosition)
+	return NewCoordinate(c.X+l.pathOffset.X, c.Y+l.pathOffset.Y, c.Z+l.pathOffset.Z)
+}
+
+// IsLifted returns true if the end effector is above the stride path (off the ground)
+func (l *Leg) IsLifted() bool {
+	return l.lift > 0
+}
+
+// IsLanding returns true if the leg is in the stance phase, but has not yet completed the swing phase arc
+func (l *Leg) IsLanding() bool {
+	return !l.isSwinging && l.lift > 0
+}
+
+// pathAt returns the location a fraction (0-1) of the way along a stride path.
+// Fractions outside the path are extrapolated from the first or last part of the path
+func pathAt(path IntermediateEffectorCoordinates, fraction float64) Coordinate {
+	if len(path) < 2 {
+		if len(path) == 0 {
+			return Coordinate{}
+		}
+		return path[0]
+	}
+	index := fraction * float64(len(path)-1)
+	i := max(0, min(int(math.Floor(index)), len(path)-2))
+	t := index - float64(i)
+	a := path[i]
+	b := path[i+1]
+	return NewCoordinate(a.X+(b.X-a.X)*t, a.Y+(b.Y-a.Y)*t, a.Z+(b.Z-a.Z)*t)
+}
 
-	// Each leg has a dedicated interpolation table for each joint. The pod has the "master clock"
-	// for indexing these tables
-	if direction == Forward {
-		if l.stanceInterpolationIndex < 0 {
-			l.stanceInterpolationIndex = INTERPOLATION_STEPS - 1
-			l.swingInterpolationIndex = 0
+// pathFraction returns the fraction of the way along a stride path (see pathAt) closest to c in the XY plane
+func pathFraction(path IntermediateEffectorCoordinates, c Coordinate) float64 {
+	best := 0.0
+	bestDistance := math.Inf(1)
+	for i := 0; i < len(path)-1; i++ {
+		a := path[i]
+		b := path[i+1]
+		dx := b.X - a.X
+		dy := b.Y - a.Y
+		t := 0.0
+		if dx != 0 || dy != 0 {
+			t = ((c.X-a.X)*dx + (c.Y-a.Y)*dy) / (dx*dx + dy*dy)
+		}
+		// Only the ends of the path are extended
+		if i > 0 {
+			t = math.Max(0, t)
+		}
+		if i < len(path)-2 {
+			t = math.Min(1, t)
+		}
+		distance := math.Hypot(c.X-(a.X+t*dx), c.Y-(a.Y+t*dy))
+		if distance < bestDistance {
+			best = (float64(i) + t) / float64(len(path)-1)
+			bestDistance = distance
 		}
+	}
+	return best
+}
 
-	} else if direction == Reverse {
-		if l.stanceInterpolationIndex > INTERPOLATION_STEPS-1 {
-			l.stanceInterpolationIndex = 0
-			l.swingInterpolationIndex = INTERPOLATION_STEPS - 1
+// strideCycle returns the end effector targets for one full step cycle along a stride path (starting with
+// the swing phase) for a leg that is in step with a gait
+func strideCycle(path IntermediateEffectorCoordinates, gait *Gait, legIndex int) []Coordinate {
+	var targets []Coordinate
+	swingFraction := 1 - gait.DutyFactor
+	profile := gait.LegSwingProfile(legIndex)
+	for phase := 0.0; phase < 1; phase += gait.PhaseStep() {
+		if gait.IsSwing(phase) {
+			progress := phase / swingFraction
+			c := pathAt(path, profile.Advance(progress))
+			targets = append(targets, NewCoordinate(c.X, c.Y, c.Z-profile.Lift(progress, gait.SwingDuration())))
+		} else {
+			targets = append(targets, pathAt(path, 1-(phase-swingFraction)/gait.DutyFactor))
 		}
 	}
+	return targets
+}
+
+// moveEffector solves the IK equations for an end effector target in the base reference
+// frame (using the current body pose) and moves the leg accordingly.
+// The leg is left untouched if a solution can not be found.
+func (l *Leg) moveEffector(target Coordinate) error {
+	angles, err := SolveEffectorIK(l, target, l.debugChannel)
+	if err != nil {
+		return err
+	}
+	l.RecalculateForwardKinematics(angles)
+	return nil
 }
 
 // NewLeg returns a new leg
@@ -229,13 +433,16 @@ func NewLeg(
 	ServoAngles ServoAngles,
 	// The distance between reference frames (coxa == distance from coxa reference frame origin to femur reference frame origin)
 	SegmentLengths SegmentLengths,
+	// The kinematic chain of the leg
+	Chain DHChain,
 	// Each servo has it's own unique id. Refer to the README.md file for the numbering scheme used
-	ServoIds [NUM_JOINTS - 1]int, // 0: Coxa, 1: Femur, 2: Tibia
+	ServoIds []int, // 0: Coxa, 1: Femur, 2: Tibia, ...
 	// output channel for debug messages
 	debugChannel chan string) *Leg {
 	l := Leg{
 		OffsetTransformationMatrix: OffsetTransformationMatrix,
 		SegmentLengths:             SegmentLengths,
+		Chain:                      Chain,
 		Index:                      Index,
 		ServoAngles:                ServoAngles,
 		CoxaSeparationAngle:        CoxaSeparationAngle,
@@ -244,53 +451,74 @@ func NewLeg(
 
 	l.RecalculateForwardKinematics(ServoAngles)
 
-	l.NeutralEffectorCoordinate = l.Joints[EFFECTOR_ORIGIN_INDEX]
+	l.NeutralEffectorCoordinate = l.Effector()
 
 	return &l
 }
 
 // RevertToNutral updates the interpolation table with the steps
 // necessary for moving the leg back from the current position to
-// the nwutral / rest position
-func (l *Leg) RevertToNutral() {
+// the nwutral / rest position. Every step lasts a tick of dt seconds
+func (l *Leg) RevertToNutral(steps int, dt float64) error {
+	table, err := l.revertTable(steps, dt)
+	if err != nil {
+		return err
+	}
+	l.IntermediateAngles = table
+	return nil
+}
+
+// revertTable returns the interpolation table for moving the leg back to the neutral / rest position,
+// leaving the leg untouched
+func (l *Leg) revertTable(steps int, dt float64) (IntermediateAngles, error) {
 	targetCoordinate := l.NeutralEffectorCoordinate
-	startCoordinate := l.Joints[EFFECTOR_ORIGIN_INDEX]
+	startCoordinate := l.Effector()
 
-	stepX := (targetCoordinate.X - startCoordinate.X) / (INTERPOLATION_STEPS - 1)
-	stepY := (targetCoordinate.Y - startCoordinate.Y) / (INTERPOLATION_STEPS - 1)
-	stepZ := (targetCoordinate.Z - startCoordinate.Z) / (INTERPOLATION_STEPS - 1)
+	stepX := (targetCoordinate.X - startCoordinate.X) / float64(steps-1)
+	stepY := (targetCoordinate.Y - startCoordinate.Y) / float64(steps-1)
+	stepZ := (targetCoordinate.Z - startCoordinate.Z) / float64(steps-1)
 
-	for i := 0; i < INTERPOLATION_STEPS; i++ {
+	table := make(IntermediateAngles, steps)
+	previous := l.ServoAngles
+	for i := 0; i < steps; i++ {
 		swing := NewCoordinate(startCoordinate.X+stepX*float64(i), startCoordinate.Y+stepY*float64(i), startCoordinate.Z+stepZ*float64(i))
 
-		angles, _ := SolveEffectorIK(l, swing, l.debugChannel)
+		angles, err := SolveEffectorIK(l, swing, l.debugChannel)
+		if err != nil {
+			return nil, err
+		}
+		err = l.JointLimits.CheckSpeed(l.Index, previous, angles, dt)
+		if err != nil {
+			return nil, err
+		}
+		previous = angles
 
-		l.IntermediateAngles.JointAngle[0][i] = angles.Coxa
-		l.IntermediateAngles.JointAngle[1][i] = angles.Femur
-		l.IntermediateAngles.JointAngle[2][i] = angles.Tibia
+		table[i] = angles
 	}
+	return table, nil
 }
 
 // UpdateRevert grounds all legs, so that the pod doesn't tip over
 func (l *Leg) UpdateRevertPhase0() {
 
-	angles := NewServoAngles(l.IntermediateAngles.JointAngle[0][l.RevertInterpolationIndex], l.IntermediateAngles.JointAngle[1][l.RevertInterpolationIndex], l.IntermediateAngles.JointAngle[2][l.RevertInterpolationIndex])
+	angles := l.IntermediateAngles[l.RevertInterpolationIndex]
 	l.RecalculateForwardKinematics(angles)
-	l.ServoAngles, _ = SolveEffectorIK(l, NewCoordinate(l.Joints[EFFECTOR_ORIGIN_INDEX].X, l.Joints[EFFECTOR_ORIGIN_INDEX].Y, POD_Z_HEIGHT), l.debugChannel)
-	l.RecalculateForwardKinematics(l.ServoAngles)
+	l.moveEffector(NewCoordinate(l.Effector().X, l.Effector().Y, POD_Z_HEIGHT))
 }
 
 // UpdateRevert recalculates forward kinematic for the current interpolation step
-// and increments the interpolation index.
-func (l *Leg) UpdateRevertPhase1() int {
+// and increments the interpolation index. The leg is lifted REVERT_LIFT mm using
+// the vertical shape of a swing profile, with ticks lasting dt seconds
+func (l *Leg) UpdateRevertPhase1(profile *SwingProfile, dt float64) int {
 
-	angles := NewServoAngles(l.IntermediateAngles.JointAngle[0][l.RevertInterpolationIndex], l.IntermediateAngles.JointAngle[1][l.RevertInterpolationIndex], l.IntermediateAngles.JointAngle[2][l.RevertInterpolationIndex])
+	angles := l.IntermediateAngles[l.RevertInterpolationIndex]
 
 	l.RecalculateForwardKinematics(angles)
 
 	l.RevertInterpolationIndex += 1
 
-	if l.RevertInterpolationIndex >= INTERPOLATION_STEPS {
+	steps := len(l.IntermediateAngles)
+	if l.RevertInterpolationIndex >= steps {
 		l.IsReverting = false
 		l.RevertInterpolationIndex = 0
 		return l.Index + 1
@@ -298,24 +526,27 @@ func (l *Leg) UpdateRevertPhase1() int {
 
 	// We need to lift the legs in the swing phase, so we will modify Z target slightly
 	// when in the swing phase and then find a new solution where the leg is not touching the ground
-	phase_step := math.Pi / (INTERPOLATION_STEPS - 1)
-	z := l.Joints[EFFECTOR_ORIGIN_INDEX].Z
+	z := l.Effector().Z
 
-	// Phase should swing from 0 to pi
-	phase := phase_step * (float64(l.RevertInterpolationIndex))
-	zNew := REVERT_LIFT * math.Sin(phase)
-	l.ServoAngles, _ = SolveEffectorIK(l, NewCoordinate(l.Joints[EFFECTOR_ORIGIN_INDEX].X, l.Joints[EFFECTOR_ORIGIN_INDEX].Y, z-zNew), l.debugChannel)
-
-	l.RecalculateForwardKinematics(l.ServoAngles)
+	revert := *profile
+	revert.Height = REVERT_LIFT
+	progress := float64(l.RevertInterpolationIndex) / float64(steps-1)
+	zNew := revert.Lift(progress, float64(steps-1)*dt)
+	l.moveEffector(NewCoordinate(l.Effector().X, l.Effector().Y, z-zNew))
 
 	return l.Index
 }
 
-// ResetInterpolator reset both the swing and stance interpolation indices
-// for all ltegs to the center of their respective interpolation tables
+// ResetInterpolator moves the leg's position on the stride path to the center of the path (where the
+// end effector is in the neutral stance). The leg catches up with the gait when it starts walking
 func (l *Leg) ResetInterpolator() {
-	l.swingInterpolationIndex = (INTERPOLATION_STEPS - 1) / 2
-	l.stanceInterpolationIndex = float64(l.swingInterpolationIndex)
+	l.pathPosition = 0.5
+	l.isSwinging = false
+	l.isInStep = false
+	l.liftProgress = 1
+	l.liftRate = 0
+	l.lift = 0
+	l.pathOffset = Coordinate{}
 }
 
 // Zero resets all servo angles in the leg to 0 degrees. This should result in the pod having all legs stretched
@@ -324,19 +555,21 @@ func (l *Leg) ResetInterpolator() {
 // satisfied.
 // This is a prerequisit for the FK/IK math to make sense in meat space ;)
 func (l *Leg) Zero() {
-	l.ServoAngles.Coxa = 0
-	l.ServoAngles.Femur = 0
-	l.ServoAngles.Tibia = 0
-	l.RecalculateForwardKinematics(l.ServoAngles)
+	l.RecalculateForwardKinematics(ServoAngles{})
 }
 
 // Ground anchors the end effector to a given Z-height
+// An error is returned (and the leg is left untouched) if the end effector is unable to reach the height
 func (l *Leg) Ground(height float64) error {
-	var err error
-	l.ServoAngles, err = SolveEffectorIK(l, NewCoordinate(l.Joints[EFFECTOR_ORIGIN_INDEX].X, l.Joints[EFFECTOR_ORIGIN_INDEX].Y, height), l.debugChannel)
+	angles, err := l.groundAngles(height)
 	if err != nil {
 		return err
 	}
-	l.RecalculateForwardKinematics(l.ServoAngles)
+	l.RecalculateForwardKinematics(angles)
 	return nil
 }
+
+// groundAngles returns the servo angles anchoring the end effector to a given Z-height
+func (l *Leg) groundAngles(height float64) (ServoAngles, error) {
+	return SolveEffectorIK(l, NewCoordinate(l.Effector().X, l.Effector().Y, height), l.debugChannel)
+}
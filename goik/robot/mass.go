@@ -0,0 +1,99 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+// Mass (in grams) of a single XL-320 servo
+const XL320_MASS = 16.7
+
+// NewXL320LegMasses returns the masses for a leg built from XL-320 servos
+// with the given segment masses (in grams, ordered along the kinematic chain)
+func NewXL320LegMasses(segments ...float64) LegMasses {
+	m := LegMasses{Segments: segments}
+	for range segments {
+		m.Servos = append(m.Servos, XL320_MASS)
+	}
+	return m
+}
+
+// PointMass is a mass (in grams) located at a point in the base reference frame
+type PointMass struct {
+	Mass     float64
+	Location Coordinate
+}
+
+// PointMasses returns the point masses making up a leg, located using the current joint
+// positions. Servo masses are located at the joint origins and segment masses halfway
+// between the joints they connect.
+func (l *Leg) PointMasses(masses LegMasses) []PointMass {
+	var points []PointMass
+	for j := 0; j < len(l.Joints)-1; j++ {
+		if j < len(masses.Servos) && masses.Servos[j] != 0 {
+			points = append(points, PointMass{Mass: masses.Servos[j], Location: l.Joints[j]})
+		}
+		if j < len(masses.Segments) && masses.Segments[j] != 0 {
+			a := l.Joints[j]
+			b := l.Joints[j+1]
+			points = append(points, PointMass{Mass: masses.Segments[j], Location: NewCoordinate((a.X+b.X)/2, (a.Y+b.Y)/2, (a.Z+b.Z)/2)})
+		}
+	}
+	return points
+}
+
+// PointMasses returns all point masses making up the pod (body, payload and legs)
+// in the base reference frame, using the current body pose and joint positions
+func (p *Pod) PointMasses() []PointMass {
+	masses := p.BodyDefinition.Masses
+	if masses == nil {
+		return nil
+	}
+
+	var points []PointMass
+	if masses.Body != 0 {
+		points = append(points, PointMass{Mass: masses.Body, Location: p.BodyPose.Translation})
+	}
+	if masses.Payload != 0 {
+		points = append(points, PointMass{Mass: masses.Payload, Location: p.BodyPose.ToBaseFrame(masses.PayloadPosition)})
+	}
+	for i, l := range p.Legs {
+		points = append(points, l.PointMasses(p.BodyDefinition.GetLegMasses(i))...)
+	}
+	return points
+}
+
+// TotalMass returns the total mass (in grams) of the pod. 0 if no masses have been defined
+func (p *Pod) TotalMass() float64 {
+	total := 0.0
+	for _, m := range p.PointMasses() {
+		total += m.Mass
+	}
+	return total
+}
+
+// CenterOfMass returns the centre of mass of the pod in the base reference frame.
+// Without a mass model (BodyDefinition.Masses) this is the origin of the body reference frame.
+func (p *Pod) CenterOfMass() Coordinate {
+	var total float64
+	var com Coordinate
+	for _, m := range p.PointMasses() {
+		total += m.Mass
+		com.X += m.Mass * m.Location.X
+		com.Y += m.Mass * m.Location.Y
+		com.Z += m.Mass * m.Location.Z
+	}
+	if total == 0 {
+		return p.BodyPose.Translation
+	}
+	return NewCoordinate(com.X/total, com.Y/total, com.Z/total)
+}
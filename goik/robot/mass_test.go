@@ -0,0 +1,74 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLegPointMasses(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	l := p.Legs[0]
+	points := l.PointMasses(NewXL320LegMasses(5, 10, 15))
+	if len(points) != 6 {
+		t.Fatalf("%d point masses, want 6", len(points))
+	}
+
+	// Servos at the joint origins, segments halfway between the joints
+	for j := 0; j < 3; j++ {
+		servo, segment := points[2*j], points[2*j+1]
+		if servo.Mass != XL320_MASS || servo.Location != l.Joints[j] {
+			t.Errorf("joint %d: servo %+v", j, servo)
+		}
+		a, b := l.Joints[j], l.Joints[j+1]
+		middle := NewCoordinate((a.X+b.X)/2, (a.Y+b.Y)/2, (a.Z+b.Z)/2)
+		if segment.Mass != []float64{5, 10, 15}[j] || !closeTo(segment.Location, middle, 1e-9) {
+			t.Errorf("joint %d: segment %+v, want %2.2f g at %+v", j, segment, []float64{5, 10, 15}[j], middle)
+		}
+	}
+
+	if points := l.PointMasses(LegMasses{}); len(points) != 0 {
+		t.Errorf("%d point masses for a massless leg", len(points))
+	}
+}
+
+func TestCenterOfMass(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	want := 150 + 6*(5+10+15+3*XL320_MASS)
+	if math.Abs(p.TotalMass()-want) > 1e-9 {
+		t.Errorf("total mass %2.2f g, want %2.2f g", p.TotalMass(), want)
+	}
+
+	// The legs are placed symmetrically around the body
+	com := p.CenterOfMass()
+	if math.Abs(com.X) > 1e-6 || math.Abs(com.Y) > 1e-6 {
+		t.Errorf("centre of mass at %+v, want it centred", com)
+	}
+
+	// A payload moves the centre of mass towards it
+	p.BodyDefinition.Masses.Payload = want
+	p.BodyDefinition.Masses.PayloadPosition = NewCoordinate(40, 0, 0)
+	com = p.CenterOfMass()
+	if math.Abs(com.X-20) > 1e-6 || math.Abs(com.Y) > 1e-6 {
+		t.Errorf("centre of mass at %+v, want X 20", com)
+	}
+
+	// Without a mass model the centre of mass is the centre of the body
+	p = NewPod(NewExampleHexapod0())
+	if p.TotalMass() != 0 || p.CenterOfMass() != p.BodyPose.Translation {
+		t.Errorf("mass %2.2f g at %+v without a mass model", p.TotalMass(), p.CenterOfMass())
+	}
+}
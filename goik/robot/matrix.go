@@ -46,3 +46,15 @@ func HomogeneousTransformationMatrix(ProjectionMatrix *mat.Dense, Theta float64,
 
 	return H
 }
+
+// DenavitHartenbergMatrix returns the homogeneous transformation matrix for a link
+// described by the Denavit-Hartenberg parameters (angles in radians)
+// H = Rot_Z(Theta) x Trans_Z(D) x Trans_X(A) x Rot_X(Alpha)
+func DenavitHartenbergMatrix(Theta float64, D float64, A float64, Alpha float64) *mat.Dense {
+	return mat.NewDense(4, 4, []float64{
+		math.Cos(Theta), -math.Sin(Theta) * math.Cos(Alpha), math.Sin(Theta) * math.Sin(Alpha), A * math.Cos(Theta),
+		math.Sin(Theta), math.Cos(Theta) * math.Cos(Alpha), -math.Cos(Theta) * math.Sin(Alpha), A * math.Sin(Theta),
+		0, math.Sin(Alpha), math.Cos(Alpha), D,
+		0, 0, 0, 1,
+	})
+}
@@ -15,11 +15,14 @@
 package robot
 
 import (
+	"fmt"
 	"os"
 )
 
 // A MotionPrimitive consists of a set of joint motion sequences
 type MotionPrimitive struct {
+	// Rate (samples per second) of the recording. One sample is recorded per tick of the pod clock
+	SampleRate       float64
 	rawAngles        []ServoAngles
 	normalizedAngles []byte
 }
@@ -41,33 +44,20 @@ func (m *MotionPrimitive) Clear() {
 	m.normalizedAngles = nil
 }
 
-func (m *MotionPrimitive) normalize(servoRange int, invertedCoxa bool, invertedFemur bool, invertedTibia bool) {
-	for _, a := range m.rawAngles {
-
-		var joint uint16
-		if invertedCoxa {
-			joint = 1024 - uint16((a.Coxa/float64(servoRange))*1024+512)
-		} else {
-			joint = uint16((a.Coxa/float64(servoRange))*1024 + 512)
-		}
-		m.normalizedAngles = append(m.normalizedAngles, uint8(joint&0xFF))
-		m.normalizedAngles = append(m.normalizedAngles, uint8((joint&0xFF00)>>8))
-
-		if invertedFemur {
-			joint = 1024 - uint16((a.Femur/float64(servoRange))*1024+512)
-		} else {
-			joint = uint16((a.Femur/float64(servoRange))*1024 + 512)
+// normalize converts all recorded angles to raw servo units. The angles of the legs are recorded in leg order,
+// and joints[l] is the number of joints of leg l. inverted[i] is true if joint i (in kinematic chain order) is mounted mirrored
+func (m *MotionPrimitive) normalize(servoRange int, inverted []bool, joints []int) {
+	for s, a := range m.rawAngles {
+		for i, angle := range a.Values(joints[s%len(joints)]) {
+			var joint uint16
+			if i < len(inverted) && inverted[i] {
+				joint = 1024 - uint16((angle/float64(servoRange))*1024+512)
+			} else {
+				joint = uint16((angle/float64(servoRange))*1024 + 512)
+			}
+			m.normalizedAngles = append(m.normalizedAngles, uint8(joint&0xFF))
+			m.normalizedAngles = append(m.normalizedAngles, uint8((joint&0xFF00)>>8))
 		}
-		m.normalizedAngles = append(m.normalizedAngles, uint8(joint&0xFF))
-		m.normalizedAngles = append(m.normalizedAngles, uint8((joint&0xFF00)>>8))
-
-		if invertedTibia {
-			joint = 1024 - uint16((a.Tibia/float64(servoRange))*1024+512)
-		} else {
-			joint = uint16((a.Tibia/float64(servoRange))*1024 + 512)
-		}
-		m.normalizedAngles = append(m.normalizedAngles, uint8(joint&0xFF))
-		m.normalizedAngles = append(m.normalizedAngles, uint8((joint&0xFF00)>>8))
 	}
 }
 
@@ -75,7 +65,11 @@ func (m *MotionPrimitive) createFile(path string) error {
 	return os.WriteFile(path, m.normalizedAngles, 0644)
 }
 
-func (m *MotionPrimitive) Export(path string, servoRange int, invertedCoxa bool, invertedFemur bool, invertedTibia bool) error {
-	m.normalize(servoRange, invertedCoxa, invertedFemur, invertedTibia)
+// Export writes the recorded angles to a file in raw servo units (see normalize)
+func (m *MotionPrimitive) Export(path string, servoRange int, inverted []bool, joints []int) error {
+	if len(joints) == 0 {
+		return fmt.Errorf("the number of joints of each leg is required")
+	}
+	m.normalize(servoRange, inverted, joints)
 	return m.createFile(path)
 }
@@ -0,0 +1,76 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// A leg with 2 joints is recorded and exported with 2 angles per leg
+func TestExportTwoJointLegs(t *testing.T) {
+	p := NewPod(NewTwoJointHexapod())
+	reference := NewPod(NewTwoJointHexapod())
+	p.StartRecording()
+
+	coxaAngles := []float64{0, 10, 20}
+	femur := 60.0
+	for _, coxa := range coxaAngles {
+		for _, l := range p.Legs {
+			// Rotating the coxa keeps the foot within reach of the leg
+			target := reference.Legs[l.Index]
+			target.RecalculateForwardKinematics(ServoAngles{Coxa: coxa, Femur: femur})
+			angles, err := SolveEffectorIK(l, target.Effector(), nil)
+			if err != nil {
+				t.Fatalf("leg %d: %v", l.Index, err)
+			}
+			l.RecalculateForwardKinematics(angles)
+			p.MotionPrimitive.Add(l.ServoAngles)
+		}
+	}
+
+	joints := make([]int, len(p.Legs))
+	for i, l := range p.Legs {
+		joints[i] = l.NumServos()
+	}
+	path := filepath.Join(t.TempDir(), "primitive")
+	err := p.MotionPrimitive.Export(path, 300, []bool{false, true}, joints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data) != len(coxaAngles)*len(p.Legs)*2*2 {
+		t.Fatalf("exported %d bytes, want %d", len(data), len(coxaAngles)*len(p.Legs)*2*2)
+	}
+	for s, coxa := range coxaAngles {
+		for l := range p.Legs {
+			offset := (s*len(p.Legs) + l) * 4
+			raw := []int{int(data[offset]) | int(data[offset+1])<<8, int(data[offset+2]) | int(data[offset+3])<<8}
+			// The femur is mounted mirrored
+			want := []float64{coxa/300*1024 + 512, 1024 - (femur/300*1024 + 512)}
+			for j := range raw {
+				if math.Abs(float64(raw[j])-want[j]) > 1 {
+					t.Errorf("sample %d, leg %d, %s: raw value %d, want %2.0f", s, l, JointName(j), raw[j], want[j])
+				}
+			}
+		}
+	}
+}
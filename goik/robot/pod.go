@@ -28,9 +28,13 @@ const (
 	Reverse Direction = -1
 )
 
-// Number of interpolation steps for leg movement (Keep this number an odd number).
+// Default number of interpolation steps for leg movement (Keep this number an odd number).
+// See SetInterpolationSteps
 const INTERPOLATION_STEPS = 21
 
+// Upper bound for the number of interpolation steps
+const MAX_INTERPOLATION_STEPS = 1001
+
 // Distance from base reference frame Z to end effector Z when the robot is in the rest / neutral stance
 var POD_Z_HEIGHT float64
 
@@ -54,18 +58,34 @@ type Pod struct {
 	// Contains the parameters defining the robot topology
 	// Number of legs, rest angles, segment length etc
 	BodyDefinition *BodyDefinition
-	// True if a target vector or rotation has been defined
-	HasDefinedStride bool
-	// True if the robot is moving
-	IsWalking bool
+	// Motion state of the pod (see podState.go)
+	state PodState
+	// State the pod returns to when the body has reached a new pose
+	resumeState PodState
+	// Functions receiving the events of the pod (see AddEventListener)
+	eventListeners []func(PodEvent)
 	// How many gait cycle indices should we go through for the current gait ?
 	targetGaitCycles int
 	// Which gait cycle are we in
 	currentGaitCycle int
-	// Index in the pattern cycle of the current gait
-	CurrentGaitIndex int
-	// True of the pod is in the process of moving the legs back to a neutral/ rest stance
-	IsReverting bool
+	// Phase (0-1) of the gait cycle clock. The phase of each leg is offset from the
+	// cycle phase by the leg's phase offset in the current gait
+	CyclePhase float64
+	// How far (0-1) the pod has travelled through the current gait cycle
+	cycleProgress float64
+	// Gait waiting for a compatible phase before it replaces the current gait (see SetGait)
+	pendingGait *Gait
+	// Number of ticks the pending gait has been waiting
+	pendingGaitTicks int
+	// Velocity of the body in the velocity mode (nil if the stride is set with SetStrideVector or SetRotation)
+	Velocity *Velocity
+	// Point (base reference frame) the body turns around in the current stride (nil if the stride does not turn the body)
+	StridePivot *Coordinate
+	// Selects the gait from the walking speed (nil if the gait is selected manually)
+	AutoGait *AutoGait
+	// Gait type most recently selected by AutoGait
+	autoGaitType     GaitType
+	autoGaitSelected bool
 	// Index of the Leg that is currently reverting
 	RevertingLegIndex int
 	// The process of reverting the legs to neutral position consists of two
@@ -76,6 +96,26 @@ type Pod struct {
 	IsRecording bool
 	// If recording is true, all angle changes will be recorded in MotionPrimitive
 	MotionPrimitive *MotionPrimitive
+	// BodyPose is the current rotation and translation of the robot body
+	// in relation to the base reference frame
+	BodyPose BodyPose
+	// True if the pod is in the process of moving the body to a new pose
+	posing bool
+	// Intermediate body poses (and the corresponding servo angles for each leg)
+	// for moving the body from one pose to the next
+	intermediatePoses      []BodyPose
+	intermediatePoseAngles [][]ServoAngles
+	// Current index in the body pose interpolation table
+	poseInterpolationIndex int
+	// Number of interpolation steps used when planning strides, body poses and reverts.
+	// The swing phase of the gait lasts the same number of ticks (see SetInterpolationSteps)
+	InterpolationSteps int
+	// Clock is the fixed time step simulation clock. Every call to Update advances the pod one tick
+	Clock *Clock
+	// Stability is the static stability of the pod, updated every tick
+	Stability Stability
+	// Workspace is the sampled workspace of a single leg shown in the simulator views (nil if none)
+	Workspace *Workspace
 	// direction specifies forward/reverse in the direction of the stride vector
 	// or clockwise/anticlockwise for rotation
 	direction Direction
@@ -123,6 +163,11 @@ func (p *Pod) SetCoxaLength(legNum int, length float64) error {
 		return fmt.Errorf("Unable to modify leg %d. The current body definition only has %d legs", legNum, p.BodyDefinition.NumLegs)
 	}
 
+	err := p.checkSegmentLengths(legNum)
+	if err != nil {
+		return err
+	}
+
 	p.BodyDefinition.Segments[legNum].Coxa = length
 	p.UpdatePodStructure()
 	return nil
@@ -134,6 +179,11 @@ func (p *Pod) SetFemurLength(legNum int, length float64) error {
 		return fmt.Errorf("Unable to modify leg %d. The current body definition only has %d legs", legNum, p.BodyDefinition.NumLegs)
 	}
 
+	err := p.checkSegmentLengths(legNum)
+	if err != nil {
+		return err
+	}
+
 	p.BodyDefinition.Segments[legNum].Femur = length
 	p.UpdatePodStructure()
 	return nil
@@ -145,18 +195,39 @@ func (p *Pod) SetTibiaLength(legNum int, length float64) error {
 		return fmt.Errorf("Unable to modify leg %d. The current body definition only has %d legs", legNum, p.BodyDefinition.NumLegs)
 	}
 
+	err := p.checkSegmentLengths(legNum)
+	if err != nil {
+		return err
+	}
+
 	p.BodyDefinition.Segments[legNum].Tibia = length
 	p.UpdatePodStructure()
 	return nil
 }
 
-// SetFemurAngle redefines the angle of the femur joint
+// checkSegmentLengths returns an error if the segment lengths of a leg can not be changed. A leg defined by
+// its own kinematic chain takes the lengths from the chain (see BodyDefinition.GetSegmentLengths)
+func (p *Pod) checkSegmentLengths(legNum int) error {
+	if p.BodyDefinition.HasChain(legNum) {
+		return fmt.Errorf("leg %d is defined by a kinematic chain. Change the link lengths of the chain in the body definition instead", legNum)
+	}
+	return nil
+}
+
+// SetCoxaAngle redefines the angle of the coxa joint
 func (p *Pod) SetCoxaAngle(legNum int, angle float64) error {
 	if legNum > p.BodyDefinition.NumLegs-1 {
 		return fmt.Errorf("Unable to modify leg %d. The current body definition only has %d legs", legNum, p.BodyDefinition.NumLegs)
 	}
 
-	p.BodyDefinition.RestAngles[legNum].Coxa = angle
+	angles := p.BodyDefinition.RestAngles[legNum]
+	angles.Coxa = angle
+	err := p.checkRestAngles(legNum, angles)
+	if err != nil {
+		return err
+	}
+
+	p.BodyDefinition.RestAngles[legNum] = angles
 	p.UpdatePodStructure()
 	return nil
 }
@@ -167,7 +238,14 @@ func (p *Pod) SetFemurAngle(legNum int, angle float64) error {
 		return fmt.Errorf("Unable to modify leg %d. The current body definition only has %d legs", legNum, p.BodyDefinition.NumLegs)
 	}
 
-	p.BodyDefinition.RestAngles[legNum].Femur = angle
+	angles := p.BodyDefinition.RestAngles[legNum]
+	angles.Femur = angle
+	err := p.checkRestAngles(legNum, angles)
+	if err != nil {
+		return err
+	}
+
+	p.BodyDefinition.RestAngles[legNum] = angles
 	p.UpdatePodStructure()
 	return nil
 }
@@ -178,7 +256,78 @@ func (p *Pod) SetTibiaAngle(legNum int, angle float64) error {
 		return fmt.Errorf("Unable to modify leg %d. The current body definition only has %d legs", legNum, p.BodyDefinition.NumLegs)
 	}
 
-	p.BodyDefinition.RestAngles[legNum].Tibia = angle
+	angles := p.BodyDefinition.RestAngles[legNum]
+	angles.Tibia = angle
+	err := p.checkRestAngles(legNum, angles)
+	if err != nil {
+		return err
+	}
+
+	p.BodyDefinition.RestAngles[legNum] = angles
+	p.UpdatePodStructure()
+	return nil
+}
+
+// checkRestAngles verifies that a set of rest angles are within the joint limits
+// and matches the knee configuration of the leg
+func (p *Pod) checkRestAngles(legNum int, angles ServoAngles) error {
+	err := p.BodyDefinition.GetJointLimits(legNum).Check(legNum, angles)
+	if err != nil {
+		return err
+	}
+
+	knee := p.BodyDefinition.GetKneeConfiguration(legNum)
+	if angles.Tibia != 0 && KneeConfigurationOf(angles) != knee {
+		return fmt.Errorf("leg %d: tibia angle %2.2f does not match the knee %s configuration of the leg", legNum, angles.Tibia, knee)
+	}
+	return nil
+}
+
+// SetKneeConfiguration selects the IK solution used for a leg. The rest angles are
+// mirrored so that the end effector stays in the same neutral position
+func (p *Pod) SetKneeConfiguration(legNum int, knee KneeConfiguration) error {
+	if legNum > p.BodyDefinition.NumLegs-1 {
+		return fmt.Errorf("Unable to modify leg %d. The current body definition only has %d legs", legNum, p.BodyDefinition.NumLegs)
+	}
+
+	// The neutral effector coordinate is calculated for a level body, so it is also a body frame coordinate
+	leg := p.Legs[legNum]
+	solutions, err := solveEffectorIKBranches(leg, leg.NeutralEffectorCoordinate, p.debugChannel)
+	if err != nil {
+		return err
+	}
+
+	err = p.BodyDefinition.GetJointLimits(legNum).Check(legNum, solutions[knee])
+	if err != nil {
+		return fmt.Errorf("the knee %s rest angles are outside the joint limits (%w)", knee, err)
+	}
+
+	for len(p.BodyDefinition.Knees) < p.BodyDefinition.NumLegs {
+		p.BodyDefinition.Knees = append(p.BodyDefinition.Knees, KneeUp)
+	}
+
+	p.BodyDefinition.Knees[legNum] = knee
+	p.BodyDefinition.RestAngles[legNum] = solutions[knee]
+	p.UpdatePodStructure()
+	return nil
+}
+
+// SetJointLimits redefines the range of motion for the joints in a leg
+func (p *Pod) SetJointLimits(legNum int, limits JointLimits) error {
+	if legNum > p.BodyDefinition.NumLegs-1 {
+		return fmt.Errorf("Unable to modify leg %d. The current body definition only has %d legs", legNum, p.BodyDefinition.NumLegs)
+	}
+
+	err := limits.Check(legNum, p.BodyDefinition.RestAngles[legNum])
+	if err != nil {
+		return fmt.Errorf("the rest angles are outside the new limits (%w)", err)
+	}
+
+	for len(p.BodyDefinition.JointLimits) < p.BodyDefinition.NumLegs {
+		p.BodyDefinition.JointLimits = append(p.BodyDefinition.JointLimits, JointLimits{})
+	}
+
+	p.BodyDefinition.JointLimits[legNum] = limits
 	p.UpdatePodStructure()
 	return nil
 }
@@ -188,6 +337,11 @@ func (p *Pod) SetTibiaAngle(legNum int, angle float64) error {
 // and femur and tibia rest angles
 func (p *Pod) UpdatePodStructure() {
 	// The robot body is flat in the XY plane in the base reference frame.
+	// Rest angles are defined for a level body, so any body pose is discarded
+	p.BodyPose = BodyPose{}
+	// The new legs have no stride
+	p.resetState()
+	servoId := 0
 	for l := 0; l < p.BodyDefinition.NumLegs; l++ {
 		// Pod body is described as an inscribed polygon with a radius r (== distance from center of robot)
 		// Leg offset Transformation matrix
@@ -198,20 +352,45 @@ func (p *Pod) UpdatePodStructure() {
 			0, 0, 0, 1,
 		})
 
-		servoIds := [NUM_JOINTS - 1]int{l*(NUM_JOINTS-1) + 1, l*(NUM_JOINTS-1) + 2, l*(NUM_JOINTS-1) + 3}
+		// Servo ids are assigned consecutively along the kinematic chain, starting with leg 0
+		chain := p.BodyDefinition.GetChain(l)
+		servoIds := make([]int, len(chain))
+		for j := range servoIds {
+			servoId++
+			servoIds[j] = servoId
+		}
+
 		p.Legs[l] = NewLeg(l,
 			p.BodyDefinition.CoxaAngles[l],
 			OffsetTransformationMatrix,
 			p.BodyDefinition.RestAngles[l],
-			p.BodyDefinition.Segments[l],
+			p.BodyDefinition.GetSegmentLengths(l),
+			chain,
 			servoIds,
 			p.debugChannel)
+		p.Legs[l].JointLimits = p.BodyDefinition.GetJointLimits(l)
+		p.Legs[l].Knee = p.BodyDefinition.GetKneeConfiguration(l)
+	}
+
+	p.Stability = p.CalculateStability()
+
+	// The leg structure may have changed, so any workspace on display must be sampled again
+	if p.Workspace != nil {
+		p.ShowWorkspace(p.Workspace.Leg)
 	}
 }
 
 func (p *Pod) LoadBodyDefinition(BodyDefinition *BodyDefinition) {
 	p.Legs = make([]*Leg, BodyDefinition.NumLegs)
 	p.direction = Forward
+	p.InterpolationSteps = INTERPOLATION_STEPS
+	if p.Clock == nil {
+		p.Clock, _ = NewClock(DEFAULT_TICK_RATE)
+	}
+	if BodyDefinition.Gait != nil {
+		p.InterpolationSteps = BodyDefinition.Gait.Steps()
+		BodyDefinition.Gait.TickRate = p.Clock.Rate
+	}
 	p.MotionPrimitive = NewMotionPrimitive()
 	p.BodyDefinition = BodyDefinition
 
@@ -224,123 +403,306 @@ func NewPod(BodyDefinition *BodyDefinition) *Pod {
 	p := Pod{}
 	p.LoadBodyDefinition(BodyDefinition)
 
-	POD_Z_HEIGHT = p.Legs[0].Joints[EFFECTOR_ORIGIN_INDEX].Z
+	POD_Z_HEIGHT = p.Legs[0].Effector().Z
 
 	return &p
 }
 
+// NumServos returns the total number of servos in the pod
+func (p *Pod) NumServos() int {
+	n := 0
+	for _, l := range p.Legs {
+		n += l.NumServos()
+	}
+	return n
+}
+
 // GetEndEffectorPositions retrieves the current coordinates for the
 // end effectors of the pod
 func (p *Pod) GetEndEffectorPositions() []Coordinate {
 	positions := make([]Coordinate, p.BodyDefinition.NumLegs)
 	for i, _ := range p.Legs {
-		positions[i] = p.Legs[i].Joints[3]
+		positions[i] = p.Legs[i].Effector()
 	}
 	return positions
 }
 
+// AddTargetGaitCycles makes the pod walk nrepeats more gait cycles than it was going to.
+// A pod walking until it is stopped completes nrepeats more cycles
 func (p *Pod) AddTargetGaitCycles(nrepeats int) {
-	p.targetGaitCycles = p.targetGaitCycles + nrepeats
+	p.targetGaitCycles = max(p.targetGaitCycles, p.currentGaitCycle) + nrepeats
+}
+
+// validateInterpolationSteps returns an error if a number of interpolation steps is unusable.
+// The number must be odd, so that the middle of a stride is one of the steps
+func validateInterpolationSteps(steps int) error {
+	if steps < 3 || steps > MAX_INTERPOLATION_STEPS || steps%2 == 0 {
+		return fmt.Errorf("the number of interpolation steps must be an odd number between 3 and %d (was %d)", MAX_INTERPOLATION_STEPS, steps)
+	}
+	return nil
+}
+
+// SetInterpolationSteps changes the number of interpolation steps. Strides, body poses and reverts
+// planned from now on use the new number of steps, and the swing phase of the gait lasts the same
+// number of ticks. Fewer steps give coarser (and smaller) motion primitives, more steps give smoother motion.
+// A walking pod switches to the new timing at the next compatible phase of the gait (see SetGait)
+func (p *Pod) SetInterpolationSteps(steps int) error {
+	err := validateInterpolationSteps(steps)
+	if err != nil {
+		return err
+	}
+
+	if gait := p.NextGait(); gait.Steps() != steps {
+		retimed := *gait
+		retimed.InterpolationSteps = steps
+		err = p.SetGait(&retimed)
+		if err != nil {
+			return err
+		}
+	}
+	p.InterpolationSteps = steps
+	return nil
 }
 
 // SetStrideVector sets up the path of a single step and calculates
 // the series of intermediate angles necessary to complete a step
 // in the direction of this vector with a stride length equal to
-// the length of the vector
+// the length of the vector. The path is centered on the neutral stance,
+// so a walking pod can change its stride without drifting
 func (p *Pod) SetStrideVector(nrepeats int, x float64, y float64) error {
-	p.targetGaitCycles = nrepeats
-
-	for l, leg := range p.Legs {
-		ee := leg.Joints[EFFECTOR_ORIGIN_INDEX]
-
-		xMax := ee.X + x
-		xMin := ee.X - x
-		yMax := ee.Y + y
-		yMin := ee.Y - y
+	err := p.checkStrideChange()
+	if err != nil {
+		return err
+	}
+	err = p.setStridePaths(func(leg *Leg) IntermediateEffectorCoordinates {
+		return strideVectorPath(neutralEffector(leg), x, y, p.InterpolationSteps)
+	})
+	if err != nil {
+		return err
+	}
+	p.setTargetGaitCycles(nrepeats)
+	p.Velocity = nil
+	p.StridePivot = nil
 
-		// We need this to visualize the end effector trajectory in the simulator
-		xStep := (xMax - xMin) / (INTERPOLATION_STEPS - 1)
-		yStep := (yMax - yMin) / (INTERPOLATION_STEPS - 1)
+	p.defineStride()
+	return nil
+}
 
-		deltaX := 0.0
-		deltaY := 0.0
-		for i := 0; i < INTERPOLATION_STEPS; i++ {
-			swing := NewCoordinate(xMin+deltaX, yMin+deltaY, POD_Z_HEIGHT)
-			servoAngles, err := SolveEffectorIK(p.Legs[l], swing, p.debugChannel)
-			if err != nil {
-				return err
-			}
-			leg.IntermediateAngles.JointAngle[0][i] = servoAngles.Coxa
-			leg.IntermediateAngles.JointAngle[1][i] = servoAngles.Femur
-			leg.IntermediateAngles.JointAngle[2][i] = servoAngles.Tibia
+// SetRotation is similar to SetStrideVector, but instead of having the
+// end effector following a vector, it will follow a curve segment
+// (see SetRotationAround for turning around other points than the body origin)
+func (p *Pod) SetRotation(nrepeats int, degrees float64) error {
+	err := p.checkStrideChange()
+	if err != nil {
+		return err
+	}
+	err = p.setStridePaths(func(leg *Leg) IntermediateEffectorCoordinates {
+		return rotationPath(neutralEffector(leg), degrees, p.InterpolationSteps)
+	})
+	if err != nil {
+		return err
+	}
+	p.setTargetGaitCycles(nrepeats)
+	p.Velocity = nil
+	p.StridePivot = &Coordinate{}
 
-			leg.IntermediateEffectorCoordinates[i] = NewCoordinate(deltaX+xMin, deltaY+yMin, 0)
+	p.defineStride()
+	return nil
+}
 
-			deltaX += xStep
-			deltaY += yStep
+// setStridePaths sets the stride path of every leg. The paths of all legs are planned before any leg
+// is changed, so an error is returned (and all legs are left untouched) if any leg is unable to follow its path
+func (p *Pod) setStridePaths(path func(leg *Leg) IntermediateEffectorCoordinates) error {
+	paths := make([]IntermediateEffectorCoordinates, len(p.Legs))
+	angles := make([]IntermediateAngles, len(p.Legs))
+	for i, leg := range p.Legs {
+		paths[i] = path(leg)
+		var err error
+		angles[i], err = p.planStridePath(leg, paths[i])
+		if err != nil {
+			return err
 		}
-
 	}
+	for i, leg := range p.Legs {
+		p.applyStridePath(leg, paths[i], angles[i])
+	}
+	return nil
+}
 
-	p.HasDefinedStride = true
+// setStridePath calculates the intermediate angles necessary for a leg to follow a stride path,
+// and sets the path of the leg (see applyStridePath)
+func (p *Pod) setStridePath(leg *Leg, path IntermediateEffectorCoordinates) error {
+	angles, err := p.planStridePath(leg, path)
+	if err != nil {
+		return err
+	}
+	p.applyStridePath(leg, path, angles)
 	return nil
 }
 
-// SetRotation is similar to SetStrideVector, but instead of having the
-// end effector following a vector, it will follow a curve segment
-func (p *Pod) SetRotation(nrepeats int, degrees float64) error {
-	p.targetGaitCycles = nrepeats
+// planStridePath calculates the intermediate angles necessary for a leg to follow a stride path,
+// leaving the leg untouched. The speed limits of the joints are checked against the motion
+// of every tick of the gait cycle (see checkStridePath)
+func (p *Pod) planStridePath(leg *Leg, path IntermediateEffectorCoordinates) (IntermediateAngles, error) {
+	angles := make(IntermediateAngles, len(path))
+	for i := range path {
+		servoAngles, err := SolveEffectorIK(leg, path[i], p.debugChannel)
+		if err != nil {
+			return nil, err
+		}
+		angles[i] = servoAngles
+	}
+	if leg.JointLimits.HasSpeedLimit() {
+		err := p.checkStridePath(leg, path, p.NextGait())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return angles, nil
+}
 
-	for l, leg := range p.Legs {
-		ee := leg.Joints[EFFECTOR_ORIGIN_INDEX]
+// applyStridePath sets the stride path (and the intermediate angles) of a leg.
+// A leg that is walking continues from the point on the new path closest to where it is,
+// and moves onto the new path over the rest of its current phase
+func (p *Pod) applyStridePath(leg *Leg, path IntermediateEffectorCoordinates, angles IntermediateAngles) {
+	current := leg.pathTarget()
 
-		radius := math.Sqrt(ee.X*ee.X + ee.Y*ee.Y)
-		stepRadians := (degrees * math.Pi / 360) / (INTERPOLATION_STEPS - 1)
-		angle := math.Atan2(ee.Y, ee.X) - 0.5*degrees*math.Pi/360
-		// angle := math.Atan2(ee.Y, ee.X) - degrees*math.Pi/360
+	leg.IntermediateAngles = angles
+	// We need this to visualize the end effector trajectory in the simulator
+	leg.IntermediateEffectorCoordinates = path
 
-		var delta = 0.0
+	if leg.isInStep {
+		leg.pathPosition = pathFraction(path, current)
+		c := pathAt(path, leg.pathPosition)
+		leg.pathOffset = NewCoordinate(current.X-c.X, current.Y-c.Y, current.Z-c.Z)
+	}
+}
 
-		for i := 0; i < INTERPOLATION_STEPS; i++ {
+// SetBodyPose calculates the intermediate servo angles necessary for smoothly
+// moving the robot body from the current pose to the target pose while keeping
+// all end effectors anchored. The move is carried out by subsequent calls to Update()
+// If the pod is walking, the new pose is phased in while the gait keeps running.
+// The pose then stays in effect for all subsequent strides.
+func (p *Pod) SetBodyPose(target BodyPose) error {
+	if p.IsReverting() || p.posing {
+		return fmt.Errorf("unable to change body pose while the pod is reverting or posing")
+	}
+
+	if p.HasDefinedStride() {
+		err := p.validateStride(target)
+		if err != nil {
+			return err
+		}
+	}
+
+	steps := p.InterpolationSteps
+	poses := make([]BodyPose, steps)
+	poseAngles := make([][]ServoAngles, steps)
+	for i := 0; i < steps; i++ {
+		pose := p.BodyPose.Interpolate(target, float64(i)/float64(steps-1))
+		poses[i] = pose
 
-			x := radius * math.Cos(angle+delta)
-			y := radius * math.Sin(angle+delta)
-			swing := NewCoordinate(x, y, POD_Z_HEIGHT)
-			servoAngles, err := SolveEffectorIK(p.Legs[l], swing, p.debugChannel)
+		// When walking, the legs are solved against the new body pose as part of the gait
+		if p.IsWalking() {
+			continue
+		}
+
+		angles, err := SolveBodyIK(p.Legs, pose.Rotation, pose.Translation, p.debugChannel)
+		if err != nil {
+			return err
+		}
+		for l, leg := range p.Legs {
+			from := leg.ServoAngles
+			if i > 0 {
+				from = poseAngles[i-1][l]
+			}
+			err = leg.JointLimits.CheckSpeed(leg.Index, from, angles[l], p.Clock.Dt())
 			if err != nil {
 				return err
 			}
-			leg.IntermediateAngles.JointAngle[0][i] = servoAngles.Coxa
-			leg.IntermediateAngles.JointAngle[1][i] = servoAngles.Femur
-			leg.IntermediateAngles.JointAngle[2][i] = servoAngles.Tibia
+		}
+		poseAngles[i] = angles
+	}
+
+	p.intermediatePoses = poses
+	p.intermediatePoseAngles = poseAngles
+	p.poseInterpolationIndex = 0
+	p.posing = true
+	// A walking pod phases in the pose without a change of state
+	if !p.IsWalking() {
+		p.resumeState = p.state
+		return p.setState(Posing)
+	}
+	return nil
+}
+
+// validateStride checks that every leg is able to reach all end effector targets
+// of the current stride (including the lifted swing targets) with the body in the given pose
+func (p *Pod) validateStride(pose BodyPose) error {
+	for _, leg := range p.Legs {
+		for _, target := range strideCycle(leg.IntermediateEffectorCoordinates, p.NextGait(), leg.Index) {
+			_, err := solveEffectorIK(leg, pose.ToBodyFrame(target), p.debugChannel)
+			if err != nil {
+				return fmt.Errorf("leg %d can not complete the stride in this body pose: %w", leg.Index, err)
+			}
+		}
+	}
+	return nil
+}
 
-			leg.IntermediateEffectorCoordinates[i] = NewCoordinate(x, y, 0)
+// UpdatePosing moves the robot body one step closer to the target pose
+func (p *Pod) UpdatePosing() {
+	if !p.posing {
+		return
+	}
 
-			delta += stepRadians
+	p.BodyPose = p.intermediatePoses[p.poseInterpolationIndex]
+	for i, l := range p.Legs {
+		l.BodyPose = p.BodyPose
+		// While walking, the gait moves the legs (solving against the new pose)
+		if p.IsWalking() {
+			continue
+		}
+		if angles := p.intermediatePoseAngles[p.poseInterpolationIndex]; angles != nil {
+			l.RecalculateForwardKinematics(angles[i])
+		} else {
+			// The pose was planned while walking, but the pod has stopped. Keep the end effector anchored
+			l.moveEffector(l.Effector())
+		}
+		if p.IsRecording {
+			p.MotionPrimitive.Add(l.ServoAngles)
 		}
 	}
 
-	p.HasDefinedStride = true
-	return nil
+	p.poseInterpolationIndex += 1
+	if p.poseInterpolationIndex >= len(p.intermediatePoses) {
+		p.poseInterpolationIndex = 0
+		p.posing = false
+		p.emit(PodEvent{Type: PoseReached})
+		if p.state == Posing {
+			p.setState(p.resumeState)
+		}
+	}
 }
 
-// Start allows any calls to Update() to start cycling through the current gait pattern
+// Start allows any calls to Update() to start cycling through the current gait
 func (p *Pod) Start() error {
-	if !p.HasDefinedStride {
+	if !p.HasDefinedStride() {
 		return fmt.Errorf("no target / stride has been defined")
 	}
-	p.IsWalking = true
-	return nil
+	return p.setState(Walking)
 }
 
-// Stop will halt the gait cycle
+// Stop will halt the gait cycle. The pod stops at the next call to Update()
 func (p *Pod) Stop() {
 	p.targetGaitCycles = p.currentGaitCycle
-	p.CurrentGaitIndex = 0
+	if p.state == Walking {
+		p.setState(Stopping)
+	}
 }
 
-// ResetInterpolator reset both the swing and stance interpolation indices
-// for all ltegs to the center of their respective interpolation tables
+// ResetInterpolator moves all legs to the center of their stride paths
+// (where the end effectors are in the neutral stance)
 func (p *Pod) ResetInterpolator() {
 	for _, l := range p.Legs {
 		l.ResetInterpolator()
@@ -349,58 +711,44 @@ func (p *Pod) ResetInterpolator() {
 
 // IsSwingPhase returns true if the leg with index == legIndex is currently in the swing phase
 func (p *Pod) IsSwingPhase(legIndex int) bool {
-	return (*p.BodyDefinition.Gait.Pattern)[legIndex][p.CurrentGaitIndex] == 1
+	gait := p.BodyDefinition.Gait
+	return gait.IsSwing(gait.LegPhase(legIndex, p.CyclePhase)) || p.Legs[legIndex].IsLanding()
 }
 
-// UpdateMovement cycles through the set of legs, updates swing and stance interpolation indices,
-// recalculates the end effector targets for all legs and solves the inverse kinematic equations
-// necessary for mirroring the simulated movement with physical servos
+// UpdateMovement advances the gait cycle clock one tick, moves every leg to its position in the
+// gait cycle and solves the inverse kinematic equations necessary for mirroring the simulated
+// movement with physical servos
 func (p *Pod) UpdateMovement() {
+	p.updateGaitTransition()
+
+	gait := p.BodyDefinition.Gait
+	step := float64(p.direction) * gait.PhaseStep()
 
-	// // Bail out after we have reached the target number of cycles through the gait pattern
-	// // (unless we have specified 0 repetition count. We will then have to stop it using
-	// // the "stop command" - and use "revert" to get back to the starting pose)
-	// if p.tick >= INTERPOLATION_STEPS*p.targetGaitCycles && p.targetGaitCycles != 0 {
-	// 	return
-	// }
+	p.CyclePhase = math.Mod(p.CyclePhase+step, 1)
+	if p.CyclePhase < 0 {
+		p.CyclePhase += 1
+	}
 
-	var endOfSwing bool
 	for i, l := range p.Legs {
-		if (*p.BodyDefinition.Gait.Pattern)[i][p.CurrentGaitIndex] == 1 {
-			// Swing phase
-			endOfSwing = l.UpdateSwing(p.direction)
-		} else {
-			// Stance phase
-			l.UpdateStance(p.direction, p.BodyDefinition.Gait.StanceReturnSpeedFactor)
+		legPhase := gait.LegPhase(i, p.CyclePhase)
+		// In the velocity mode, every leg plans its next stride as it lifts off
+		if gait.IsSwing(legPhase) && !l.isSwinging {
+			p.replanStride(l, gait)
 		}
-	}
-	// The folling logic is somewhat wonky...
-	if endOfSwing && p.direction == Forward {
-		p.CurrentGaitIndex += 1
-	} else if endOfSwing && p.direction == Reverse {
-		p.CurrentGaitIndex -= 1
+		l.UpdateGait(gait, legPhase, step)
 	}
 
 	p.tick += 1
 
-	// If we are finished with the current index/column in the gait pattern table
-	// we proceed to the next. A "cycle" is one full iteration through this table
+	// A "cycle" is one full turn of the gait cycle clock (in either direction)
 	// If we reach the target number of cycles, the pod will stop moving
-	if p.direction == Forward {
-		if p.CurrentGaitIndex >= p.BodyDefinition.Gait.NumIndicesInPattern {
-			p.CurrentGaitIndex = 0
-			p.currentGaitCycle += 1
-			if p.currentGaitCycle > p.targetGaitCycles && p.targetGaitCycles != 0 {
-				p.IsWalking = false
-			}
-		}
-	} else if p.direction == Reverse {
-		if p.CurrentGaitIndex < 0 {
-			p.CurrentGaitIndex = p.BodyDefinition.Gait.NumIndicesInPattern - 1
-			p.currentGaitCycle += 1
-			if p.currentGaitCycle > p.targetGaitCycles && p.targetGaitCycles != 0 {
-				p.IsWalking = false
-			}
+	p.cycleProgress += math.Abs(step)
+	if p.cycleProgress >= 1-1e-9 {
+		p.cycleProgress = math.Max(0, p.cycleProgress-1)
+		p.currentGaitCycle += 1
+		p.emit(PodEvent{Type: CycleComplete})
+		if p.targetGaitCycles != 0 && p.currentGaitCycle >= p.targetGaitCycles {
+			p.setState(Stopping)
 		}
 	}
 
@@ -414,20 +762,45 @@ func (p *Pod) UpdateMovement() {
 	}
 }
 
+// UpdateStopping lowers the legs that are in the air onto the ground (see STOP_TOUCHDOWN_SPEED). Once all
+// legs are on the ground, the gait cycle clock is reset and the pod is back in StrideDefined
+func (p *Pod) UpdateStopping() {
+	grounded := true
+	for _, l := range p.Legs {
+		if l.IsLifted() && !l.UpdateTouchdown(STOP_TOUCHDOWN_SPEED, p.Clock.Dt()) {
+			grounded = false
+		}
+	}
+	if !grounded {
+		if p.IsRecording {
+			for _, l := range p.Legs {
+				p.MotionPrimitive.Add(l.ServoAngles)
+			}
+		}
+		return
+	}
+
+	p.CyclePhase = 0
+	p.cycleProgress = 0
+	p.setState(StrideDefined)
+}
+
 // UpdateRevertingToNeutral moves a leg a step closer to the neutral position
 // One leg is updated at a time. Once one leg is back to the neutral / rest position,
 // the next leg is moved. This continues until the robot is in the neutral / rest stance
 func (p *Pod) UpdateRevertingToNeutral() {
 
-	if !p.IsReverting {
+	if !p.IsReverting() {
 		return
 	}
 
 	if p.RevertingLegIndex >= p.BodyDefinition.NumLegs {
-		p.IsReverting = false
 		p.RevertingLegIndex = 0
-		p.HasDefinedStride = false
+		p.Velocity = nil
+		p.StridePivot = nil
 		p.RevertPhase = Ground
+		p.setState(Idle)
+		p.emit(PodEvent{Type: NeutralReached})
 		return
 	}
 
@@ -437,15 +810,13 @@ func (p *Pod) UpdateRevertingToNeutral() {
 			l.UpdateRevertPhase0()
 			if p.IsRecording {
 				p.MotionPrimitive.Add(l.ServoAngles)
-
-				p.debugChannel <- fmt.Sprintf("Should really record leg %d", l.Index)
 			}
 
 		}
 		p.RevertPhase = MoveToNeutral
 	} else if p.RevertPhase == MoveToNeutral {
 		// Then move back to the rest position, one leg at a time
-		p.RevertingLegIndex = p.Legs[p.RevertingLegIndex].UpdateRevertPhase1()
+		p.RevertingLegIndex = p.Legs[p.RevertingLegIndex].UpdateRevertPhase1(p.BodyDefinition.Gait.LegSwingProfile(p.RevertingLegIndex), p.Clock.Dt())
 		if p.IsRecording {
 			for _, l := range p.Legs {
 				p.MotionPrimitive.Add(l.ServoAngles)
@@ -455,31 +826,61 @@ func (p *Pod) UpdateRevertingToNeutral() {
 }
 
 // Each call to update will increment the interpolation indices through either
-// the gait cycle or the revert cycle (depending on the current state of the
-// robot)
+// the body pose, gait cycle or the revert cycle (depending on the current state
+// of the robot)
 func (p *Pod) Update() {
-	if p.IsWalking && !p.IsReverting {
+	if p.posing {
+		p.UpdatePosing()
+	}
+
+	switch p.state {
+	case Walking:
 		if p.targetGaitCycles == 0 || (p.currentGaitCycle < p.targetGaitCycles) {
 			p.UpdateMovement()
 		} else {
-			p.Stop()
+			p.setState(Stopping)
 		}
-	}
-
-	if p.HasDefinedStride && p.IsReverting {
+	case Stopping:
+		p.UpdateStopping()
+	case Reverting:
 		p.UpdateRevertingToNeutral()
 	}
+
+	p.Stability = p.CalculateStability()
+}
+
+// IsMoving returns true while the pod walks, reverts to the neutral stance or moves the body to a new pose
+func (p *Pod) IsMoving() bool {
+	return p.state == Walking || p.state == Stopping || p.state == Reverting || p.posing
 }
 
 // RevertToNutral reverts all legs back to neutral / rest position
-func (p *Pod) RevertToNutral() {
-	if p.HasDefinedStride {
-		p.IsReverting = true
-		p.IsWalking = false
+// An error is returned (and the pod is left untouched) if any leg is
+// unable to reach the neutral position within its joint limits
+func (p *Pod) RevertToNutral() error {
+	err := p.canChangeState(Reverting)
+	if err != nil {
+		return err
 	}
-	for _, l := range p.Legs {
-		l.RevertToNutral()
+	tables := make([]IntermediateAngles, len(p.Legs))
+	for i, l := range p.Legs {
+		var err error
+		tables[i], err = l.revertTable(p.InterpolationSteps, p.Clock.Dt())
+		if err != nil {
+			return err
+		}
+	}
+	for i, l := range p.Legs {
+		l.IntermediateAngles = tables[i]
 	}
+	return p.setState(Reverting)
+}
+
+// StartRecording records the servo angles of every tick in the motion primitive.
+// The sample rate of the primitive is the tick rate of the clock
+func (p *Pod) StartRecording() {
+	p.IsRecording = true
+	p.MotionPrimitive.SampleRate = p.Clock.Rate
 }
 
 // ClearPrimitives purges all recorded data
@@ -498,3 +899,20 @@ func (p *Pod) Zero() {
 		l.Zero()
 	}
 }
+
+// Ground anchors all end effectors to a given Z-height
+// An error is returned (and the pod is left untouched) if any leg is unable to reach the height
+func (p *Pod) Ground(height float64) error {
+	angles := make([]ServoAngles, len(p.Legs))
+	for i, l := range p.Legs {
+		var err error
+		angles[i], err = l.groundAngles(height)
+		if err != nil {
+			return fmt.Errorf("leg %d is unable to reach the ground: %w", l.Index, err)
+		}
+	}
+	for i, l := range p.Legs {
+		l.RecalculateForwardKinematics(angles[i])
+	}
+	return nil
+}
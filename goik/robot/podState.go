@@ -0,0 +1,253 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import "fmt"
+
+/*
+	Notes regarding the pod state machine
+
+	1) The motion of the pod is always in one of the states below. Every change of state is validated
+	   against the allowed transitions (see podTransitions) and emitted as an event to the event listeners.
+	2) States:
+		- Idle:          The pod stands still without a stride.
+		- StrideDefined: A stride has been planned (see SetStrideVector etc), and the pod stands still.
+		- Walking:       The pod walks through the gait cycles (see Start).
+		- Stopping:      The pod has completed its gait cycles, or has been stopped. Legs in the air (mid swing)
+		                 are lowered onto the ground (see UpdateStopping). The gait cycle clock is then reset,
+		                 and the pod is back in StrideDefined.
+		- Reverting:     The legs move back to the neutral stance (see RevertToNutral). The pod is Idle
+		                 once all legs are in the neutral stance. A pod without a stride reverts as well
+		                 (legs moved by a body pose etc), so every revert ends with the neutral stance reached.
+		- Posing:        The body moves to a new pose while the legs stand still (see SetBodyPose). The pod
+		                 returns to the previous state once the pose is reached. A posing pod can not revert,
+		                 since the revert is planned from the leg angles the pose is still changing. The pod
+		                 reverts from the state it returns to, once the pose is reached.
+	3) A walking pod may also move the body to a new pose. The pose is phased in while the pod walks
+	   (see IsPosing), without a change of state.
+	4) Besides the changes of state, the pod emits an event every time it completes a gait cycle,
+	   reaches the neutral stance or reaches a new body pose. The listeners are called from Update
+	   (and the methods changing the state), on the goroutine moving the pod.
+	5) Changing the structure of the pod (segment lengths, loading a body definition etc) discards the
+	   stride and the body pose, and the pod is Idle whatever the state was.
+*/
+
+type PodState int
+
+const (
+	Idle          PodState = 0
+	StrideDefined PodState = 1
+	Walking       PodState = 2
+	Stopping      PodState = 3
+	Reverting     PodState = 4
+	Posing        PodState = 5
+)
+
+func (s PodState) String() string {
+	switch s {
+	case Idle:
+		return "idle"
+	case StrideDefined:
+		return "stride defined"
+	case Walking:
+		return "walking"
+	case Stopping:
+		return "stopping"
+	case Reverting:
+		return "reverting"
+	case Posing:
+		return "posing"
+	}
+	return fmt.Sprintf("PodState(%d)", int(s))
+}
+
+// The states each state can change to
+var podTransitions = map[PodState][]PodState{
+	Idle:          {StrideDefined, Reverting, Posing},
+	StrideDefined: {Walking, Reverting, Posing},
+	Walking:       {Stopping, Reverting},
+	Stopping:      {StrideDefined, Walking, Reverting},
+	Reverting:     {Idle},
+	Posing:        {Idle, StrideDefined, Walking},
+}
+
+type PodEventType int
+
+const (
+	// The state of the pod has changed
+	StateChanged PodEventType = 0
+	// The pod has completed a gait cycle
+	CycleComplete PodEventType = 1
+	// All legs have reverted to the neutral stance
+	NeutralReached PodEventType = 2
+	// The body has reached a new pose
+	PoseReached PodEventType = 3
+)
+
+func (t PodEventType) String() string {
+	switch t {
+	case StateChanged:
+		return "state changed"
+	case CycleComplete:
+		return "cycle complete"
+	case NeutralReached:
+		return "neutral reached"
+	case PoseReached:
+		return "pose reached"
+	}
+	return fmt.Sprintf("PodEventType(%d)", int(t))
+}
+
+// PodEvent is emitted to the event listeners of a pod (see AddEventListener)
+type PodEvent struct {
+	Type PodEventType
+	// State before and after the event (the same unless the state changed)
+	From PodState
+	To   PodState
+	// Number of gait cycles completed
+	Cycle int
+	// Tick of the pod clock
+	Tick int
+}
+
+func (e PodEvent) String() string {
+	if e.Type == StateChanged {
+		return fmt.Sprintf("%s: %s -> %s (tick %d)", e.Type, e.From, e.To, e.Tick)
+	}
+	if e.Type == CycleComplete {
+		return fmt.Sprintf("%s: cycle %d (tick %d)", e.Type, e.Cycle, e.Tick)
+	}
+	return fmt.Sprintf("%s (tick %d)", e.Type, e.Tick)
+}
+
+// State returns the motion state of the pod (see the notes above)
+func (p *Pod) State() PodState {
+	return p.state
+}
+
+// HasDefinedStride returns true if a stride has been planned (the pod may be walking or reverting from the stride)
+func (p *Pod) HasDefinedStride() bool {
+	switch p.state {
+	case StrideDefined, Walking, Stopping, Reverting:
+		return true
+	case Posing:
+		return p.resumeState == StrideDefined
+	}
+	return false
+}
+
+// IsWalking returns true while the legs follow the gait cycle
+func (p *Pod) IsWalking() bool {
+	return p.state == Walking || p.state == Stopping
+}
+
+// IsReverting returns true while the legs move back to the neutral stance
+func (p *Pod) IsReverting() bool {
+	return p.state == Reverting
+}
+
+// IsPosing returns true while the body moves to a new pose (standing still or walking)
+func (p *Pod) IsPosing() bool {
+	return p.posing
+}
+
+// WalksUntilStopped returns true while the pod walks without a number of gait cycles to complete
+// (nrepeats 0), so it keeps walking until it is stopped (see Stop)
+func (p *Pod) WalksUntilStopped() bool {
+	return p.state == Walking && p.targetGaitCycles == 0
+}
+
+// AddEventListener adds a function receiving the events of the pod (see the notes above)
+func (p *Pod) AddEventListener(listener func(PodEvent)) {
+	p.eventListeners = append(p.eventListeners, listener)
+}
+
+// canChangeState returns an error if the pod is unable to change from the current state to a new state
+func (p *Pod) canChangeState(state PodState) error {
+	if state == p.state {
+		return nil
+	}
+	for _, s := range podTransitions[p.state] {
+		if s == state {
+			return nil
+		}
+	}
+	return fmt.Errorf("the pod can not change from %s to %s", p.state, state)
+}
+
+// setState changes the state of the pod and emits the change to the event listeners.
+// An error is returned (and the state is left untouched) if the transition is not allowed
+func (p *Pod) setState(state PodState) error {
+	err := p.canChangeState(state)
+	if err != nil || state == p.state {
+		return err
+	}
+	from := p.state
+	p.state = state
+	p.emit(PodEvent{Type: StateChanged, From: from, To: state})
+	return nil
+}
+
+// resetState discards the stride and the body pose movement, and makes the pod Idle from any state (see note 5)
+func (p *Pod) resetState() {
+	p.posing = false
+	if p.state == Idle {
+		return
+	}
+	from := p.state
+	p.state = Idle
+	p.emit(PodEvent{Type: StateChanged, From: from, To: Idle})
+}
+
+// emit sends an event to the event listeners
+func (p *Pod) emit(event PodEvent) {
+	if event.Type != StateChanged {
+		event.From = p.state
+		event.To = p.state
+	}
+	event.Cycle = p.currentGaitCycle
+	if p.Clock != nil {
+		event.Tick = p.Clock.Ticks
+	}
+	for _, listener := range p.eventListeners {
+		listener(event)
+	}
+}
+
+// defineStride makes a pod with a new stride ready to walk. A walking pod keeps walking with the new stride
+func (p *Pod) defineStride() {
+	switch p.state {
+	case Idle:
+		p.setState(StrideDefined)
+	case Posing:
+		p.resumeState = StrideDefined
+	}
+}
+
+// checkStrideChange returns an error if the stride can not be changed in the current state
+func (p *Pod) checkStrideChange() error {
+	if p.state == Reverting {
+		return fmt.Errorf("unable to change the stride while the pod is reverting")
+	}
+	return nil
+}
+
+// setTargetGaitCycles makes the pod walk nrepeats more gait cycles (0 walks until the pod is stopped)
+func (p *Pod) setTargetGaitCycles(nrepeats int) {
+	p.targetGaitCycles = 0
+	if nrepeats > 0 {
+		p.targetGaitCycles = p.currentGaitCycle + nrepeats
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRevertEmitsNeutralReached(t *testing.T) {
+	tests := []struct {
+		name   string
+		stride bool
+	}{
+		{"without a stride", false},
+		{"with a stride", true},
+	}
+
+	for _, test := range tests {
+		p := NewPod(NewExampleHexapod0())
+		if test.stride {
+			if err := p.SetStrideVector(1, 20, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var events []PodEvent
+		p.AddEventListener(func(event PodEvent) {
+			events = append(events, event)
+		})
+
+		from := p.State()
+		if err := p.RevertToNutral(); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if p.State() != Reverting {
+			t.Errorf("%s: state is %s, want %s", test.name, p.State(), Reverting)
+		}
+		if updateUntilStill(t, p) == 0 {
+			t.Errorf("%s: the pod did not move", test.name)
+		}
+
+		var types []PodEventType
+		for _, event := range events {
+			types = append(types, event.Type)
+		}
+		want := []PodEventType{StateChanged, StateChanged, NeutralReached}
+		if !reflect.DeepEqual(types, want) {
+			t.Fatalf("%s: events %v, want %v", test.name, events, want)
+		}
+		if events[0].From != from || events[0].To != Reverting || events[1].From != Reverting || events[1].To != Idle {
+			t.Errorf("%s: events %v", test.name, events)
+		}
+	}
+}
+
+// Every change of state follows the allowed transitions, and is emitted once
+func TestPodStateTransitions(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	var changes [][2]PodState
+	p.AddEventListener(func(event PodEvent) {
+		if event.Type == StateChanged {
+			changes = append(changes, [2]PodState{event.From, event.To})
+		}
+	})
+
+	if err := p.Start(); err == nil {
+		t.Error("an idle pod started walking without a stride")
+	}
+	if err := p.SetStrideVector(1, 20, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	updateUntilStill(t, p)
+
+	if err := p.SetBodyPose(NewBodyPose(Coordinate{}, Coordinate{Z: 5})); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.RevertToNutral(); err == nil {
+		t.Error("the pod reverted while posing")
+	}
+	updateUntilStill(t, p)
+
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	p.Clock.Tick()
+	p.Update()
+	p.Stop()
+	updateUntilStill(t, p)
+
+	if err := p.RevertToNutral(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetStrideVector(1, 20, 0); err == nil {
+		t.Error("the stride changed while reverting")
+	}
+	updateUntilStill(t, p)
+
+	want := [][2]PodState{
+		{Idle, StrideDefined},
+		{StrideDefined, Walking},
+		{Walking, Stopping},
+		{Stopping, StrideDefined},
+		{StrideDefined, Posing},
+		{Posing, StrideDefined},
+		{StrideDefined, Walking},
+		{Walking, Stopping},
+		{Stopping, StrideDefined},
+		{StrideDefined, Reverting},
+		{Reverting, Idle},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes of state %v, want %v", changes, want)
+	}
+	for _, change := range changes {
+		if !containsState(podTransitions[change[0]], change[1]) {
+			t.Errorf("%s -> %s is not an allowed transition", change[0], change[1])
+		}
+	}
+}
+
+func containsState(states []PodState, state PodState) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// A transition that is not allowed leaves the state untouched, and emits nothing
+func TestSetStateRejected(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	emitted := 0
+	p.AddEventListener(func(event PodEvent) { emitted++ })
+
+	for _, state := range []PodState{Walking, Stopping} {
+		if err := p.setState(state); err == nil {
+			t.Errorf("the pod changed from idle to %s", state)
+		}
+	}
+	if p.State() != Idle || emitted != 0 {
+		t.Errorf("state %s and %d events after rejected transitions", p.State(), emitted)
+	}
+}
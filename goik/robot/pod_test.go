@@ -0,0 +1,168 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"testing"
+)
+
+// updateUntilStill updates the pod until it stops moving, and returns the number of updates
+func updateUntilStill(t *testing.T, p *Pod) int {
+	t.Helper()
+	for updates := 0; updates < 10000; updates++ {
+		if !p.IsMoving() {
+			return updates
+		}
+		p.Clock.Tick()
+		p.Update()
+	}
+	t.Fatalf("the pod is still moving (%s)", p.State())
+	return 0
+}
+
+// The body moves to the new pose, while the feet stay where they are
+func TestSetBodyPose(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	feet := p.GetEndEffectorPositions()
+	pose := NewBodyPose(Coordinate{X: 5, Y: -10}, Coordinate{Z: 10})
+
+	if err := p.SetBodyPose(pose); err != nil {
+		t.Fatal(err)
+	}
+	if updateUntilStill(t, p) == 0 {
+		t.Error("the body did not move")
+	}
+	if p.BodyPose != pose {
+		t.Errorf("body pose %+v, want %+v", p.BodyPose, pose)
+	}
+	for i, l := range p.Legs {
+		if l.BodyPose != pose {
+			t.Errorf("leg %d: body pose %+v, want %+v", i, l.BodyPose, pose)
+		}
+		if !closeTo(l.Effector(), feet[i], 1e-6) {
+			t.Errorf("leg %d: the foot moved from %+v to %+v", i, feet[i], l.Effector())
+		}
+	}
+
+	if err := p.SetBodyPose(NewBodyPose(Coordinate{}, Coordinate{Z: -500})); err == nil {
+		t.Error("no error raising the body 500 mm")
+	}
+}
+
+// A body pose stays in effect while walking, and may be changed while walking
+func TestBodyPoseWhileWalking(t *testing.T) {
+	for _, whileWalking := range []bool{false, true} {
+		p := NewPod(NewExampleHexapod1())
+		pose := NewBodyPose(Coordinate{Y: 5}, Coordinate{Z: 5})
+		if err := p.SetStrideVector(2, 20, 0); err != nil {
+			t.Fatal(err)
+		}
+		if !whileWalking {
+			if err := p.SetBodyPose(pose); err != nil {
+				t.Fatal(err)
+			}
+			updateUntilStill(t, p)
+		}
+		if err := p.Start(); err != nil {
+			t.Fatal(err)
+		}
+		if whileWalking {
+			p.Clock.Tick()
+			p.Update()
+			if err := p.SetBodyPose(pose); err != nil {
+				t.Fatal(err)
+			}
+			if !p.IsWalking() {
+				t.Errorf("state is %s while the pose is phased in, want %s", p.State(), Walking)
+			}
+		}
+		updateUntilStill(t, p)
+
+		if p.GetCurrentGaitCycle() != 2 {
+			t.Errorf("walking %t: completed %d gait cycles, want 2", whileWalking, p.GetCurrentGaitCycle())
+		}
+		if p.BodyPose != pose {
+			t.Errorf("walking %t: body pose %+v, want %+v", whileWalking, p.BodyPose, pose)
+		}
+		// The feet end on the ground, at one end of the stride
+		for _, l := range p.Legs {
+			neutral := l.NeutralEffectorCoordinate
+			if !closeTo(l.Effector(), Coordinate{neutral.X + 20, neutral.Y, neutral.Z}, 1e-6) &&
+				!closeTo(l.Effector(), Coordinate{neutral.X - 20, neutral.Y, neutral.Z}, 1e-6) {
+				t.Errorf("walking %t: leg %d ended at %+v (neutral %+v)", whileWalking, l.Index, l.Effector(), neutral)
+			}
+		}
+	}
+}
+
+// A body pose is rejected if a leg is unable to complete the stride in the pose
+func TestBodyPoseRejectedByStride(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	if err := p.SetStrideVector(1, 40, 0); err != nil {
+		t.Fatal(err)
+	}
+	pose := NewBodyPose(Coordinate{}, Coordinate{Z: -70})
+	if err := p.SetBodyPose(pose); err == nil {
+		t.Fatal("no error raising the body out of reach of the stride")
+	}
+	if p.IsMoving() || p.BodyPose != (BodyPose{}) {
+		t.Errorf("the pod changed (state %s, pose %+v)", p.State(), p.BodyPose)
+	}
+
+	// Without the stride, the legs are able to reach the pose
+	p = NewPod(NewExampleHexapod1())
+	if err := p.SetBodyPose(pose); err != nil {
+		t.Error(err)
+	}
+}
+
+// The stride paths and the swing phase of the gait use the number of interpolation steps
+func TestSetInterpolationSteps(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	for _, invalid := range []int{1, 2, 10, MAX_INTERPOLATION_STEPS + 1} {
+		if err := p.SetInterpolationSteps(invalid); err == nil {
+			t.Errorf("%d steps: no error", invalid)
+		}
+	}
+
+	if err := p.SetInterpolationSteps(11); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetStrideVector(1, 20, 0); err != nil {
+		t.Fatal(err)
+	}
+	for i, l := range p.Legs {
+		if len(l.IntermediateEffectorCoordinates) != 11 || len(l.IntermediateAngles) != 11 {
+			t.Errorf("leg %d: %d coordinates and %d angles, want 11", i, len(l.IntermediateEffectorCoordinates), len(l.IntermediateAngles))
+		}
+	}
+	// The tripod gait swings for half the cycle
+	gait := p.NextGait()
+	if gait.Steps() != 11 || gait.SwingTicks() != 11 || gait.CycleTicks() != 22 {
+		t.Errorf("%d steps, %2.2f swing ticks and %d ticks per cycle, want 11, 11 and 22", gait.Steps(), gait.SwingTicks(), gait.CycleTicks())
+	}
+
+	// A new gait without its own number of steps uses the steps of the pod
+	wave, err := NewHexapodGait(WAVE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetGait(wave); err != nil {
+		t.Fatal(err)
+	}
+	if p.NextGait().Steps() != 11 {
+		t.Errorf("the new gait has %d steps, want 11", p.NextGait().Steps())
+	}
+}
@@ -14,6 +14,8 @@
 
 package robot
 
+import "fmt"
+
 // Note:
 //
 //	All servos are not created equal.
@@ -23,8 +25,85 @@ type ServoAngles struct {
 	Coxa  float64 `json:"Coxa"`
 	Femur float64 `json:"Femur"`
 	Tibia float64 `json:"Tibia"`
+	// Angles for any additional joints (tarsus etc) in legs with more than
+	// three joints. The joints are ordered along the kinematic chain.
+	Extra []float64 `json:"Extra,omitempty"`
 }
 
 func NewServoAngles(Coxa float64, Femur float64, Tibia float64) ServoAngles {
 	return ServoAngles{Coxa: Coxa, Femur: Femur, Tibia: Tibia}
 }
+
+// NewServoAnglesFromValues creates servo angles from a list of joint angles
+// ordered along the kinematic chain (coxa, femur, tibia, tarsus, ...)
+func NewServoAnglesFromValues(values []float64) ServoAngles {
+	var a ServoAngles
+	for i, v := range values {
+		a.Set(i, v)
+	}
+	return a
+}
+
+// Get returns the angle of joint i in the kinematic chain
+// (0: coxa, 1: femur, 2: tibia, 3 and up: additional joints)
+func (a ServoAngles) Get(i int) float64 {
+	switch i {
+	case 0:
+		return a.Coxa
+	case 1:
+		return a.Femur
+	case 2:
+		return a.Tibia
+	}
+	if i-3 < len(a.Extra) {
+		return a.Extra[i-3]
+	}
+	return 0
+}
+
+// Set updates the angle of joint i in the kinematic chain
+func (a *ServoAngles) Set(i int, angle float64) {
+	switch i {
+	case 0:
+		a.Coxa = angle
+	case 1:
+		a.Femur = angle
+	case 2:
+		a.Tibia = angle
+	default:
+		// Copy before modifying, since copies of ServoAngles share the same slice
+		extra := make([]float64, max(len(a.Extra), i-2))
+		copy(extra, a.Extra)
+		extra[i-3] = angle
+		a.Extra = extra
+	}
+}
+
+// Len returns the number of joint angles that are defined (at least coxa, femur and tibia)
+func (a ServoAngles) Len() int {
+	return 3 + len(a.Extra)
+}
+
+// Values returns the angles of the first n joints in the kinematic chain
+func (a ServoAngles) Values(n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = a.Get(i)
+	}
+	return values
+}
+
+// JointName returns a readable name for joint i in the kinematic chain
+func JointName(i int) string {
+	switch i {
+	case 0:
+		return "coxa"
+	case 1:
+		return "femur"
+	case 2:
+		return "tibia"
+	case 3:
+		return "tarsus"
+	}
+	return fmt.Sprintf("joint %d", i)
+}
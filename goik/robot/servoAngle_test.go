@@ -0,0 +1,62 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestServoAnglesValues(t *testing.T) {
+	tests := []struct {
+		values []float64
+		len    int
+		angles ServoAngles
+	}{
+		{[]float64{10, 20}, 3, ServoAngles{Coxa: 10, Femur: 20}},
+		{[]float64{10, 20, 30}, 3, ServoAngles{Coxa: 10, Femur: 20, Tibia: 30}},
+		{[]float64{10, 20, 30, 40, 50}, 5, ServoAngles{Coxa: 10, Femur: 20, Tibia: 30, Extra: []float64{40, 50}}},
+	}
+
+	for _, test := range tests {
+		a := NewServoAnglesFromValues(test.values)
+		if !reflect.DeepEqual(a, test.angles) {
+			t.Errorf("%v: angles %+v, want %+v", test.values, a, test.angles)
+		}
+		if a.Len() != test.len {
+			t.Errorf("%v: %d angles, want %d", test.values, a.Len(), test.len)
+		}
+		if values := a.Values(len(test.values)); !reflect.DeepEqual(values, test.values) {
+			t.Errorf("%v: values %v", test.values, values)
+		}
+		if a.Get(len(test.values)+1) != 0 {
+			t.Errorf("%v: joint %d is %2.2f, want 0", test.values, len(test.values)+1, a.Get(len(test.values)+1))
+		}
+	}
+}
+
+// Copies of ServoAngles share the extra joints, so Set must not change the angles of a copy
+func TestServoAnglesSetCopy(t *testing.T) {
+	a := NewServoAnglesFromValues([]float64{10, 20, 30, 40})
+	b := a
+	b.Set(3, 45)
+	b.Set(1, 25)
+	if a.Get(3) != 40 || a.Femur != 20 {
+		t.Errorf("the original angles changed to %+v", a)
+	}
+	if b.Get(3) != 45 || b.Femur != 25 {
+		t.Errorf("angles %+v, want tarsus 45 and femur 25", b)
+	}
+}
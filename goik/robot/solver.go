@@ -17,17 +17,97 @@ package robot
 import (
 	"fmt"
 	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Parameters for the damped least squares (numerical) IK solver
+const (
+	// Maximum number of iterations before giving up
+	DLS_MAX_ITERATIONS = 200
+	// A solution is accepted when the end effector is closer than this (mm) to the target
+	DLS_TOLERANCE = 0.1
+	// Damping factor (mm). Higher values give slower but more stable convergence near singularities
+	DLS_DAMPING = 10.0
+	// Maximum change of any joint angle (degrees) in a single iteration
+	DLS_MAX_STEP = 10.0
 )
 
 // Given an end effector target coordinate, SolveEffectorIK will attempt to find a solution for
 // the coxa, femur and tibia angle that results in the end effector moving to the effectorTarget coordinate
 // If a solution can not be found, the function returns an error.
+// The target is given in the base reference frame and is mapped into the body reference
+// frame using the current body pose of the leg.
 func SolveEffectorIK(leg *Leg, effectorTarget Coordinate, debugChannel chan string) (ServoAngles, error) {
+	return solveEffectorIK(leg, leg.BodyPose.ToBodyFrame(effectorTarget), debugChannel)
+}
+
+// SolveEffectorIKBranches returns both solutions to the leg IK equations (indexed by KneeUp and KneeDown).
+// Joint limits are not taken into account. The target is given in the base reference frame.
+// If the target is out of reach, the function returns an error.
+func SolveEffectorIKBranches(leg *Leg, effectorTarget Coordinate, debugChannel chan string) ([2]ServoAngles, error) {
+	return solveEffectorIKBranches(leg, leg.BodyPose.ToBodyFrame(effectorTarget), debugChannel)
+}
+
+// SolveBodyIK keeps all end effectors anchored at their current locations and attempts to
+// find the servo angles for every leg that results in the robot body being rotated (degrees around X/Y/Z)
+// and translated by the given amounts in relation to the base reference frame.
+// If a solution can not be found for any of the legs, the function returns an error.
+func SolveBodyIK(legs []*Leg, rotation Coordinate, translation Coordinate, debugChannel chan string) ([]ServoAngles, error) {
+	pose := NewBodyPose(rotation, translation)
+	servoAngles := make([]ServoAngles, len(legs))
+
+	for i, leg := range legs {
+		angles, err := solveEffectorIK(leg, pose.ToBodyFrame(leg.Effector()), debugChannel)
+		if err != nil {
+			return nil, fmt.Errorf("[Body IK Solver] leg %d: %w", leg.Index, err)
+		}
+		servoAngles[i] = angles
+	}
+
+	return servoAngles, nil
+}
+
+// solveEffectorIK solves the leg IK equations for an end effector target given in the body reference frame
+// The solution always uses the knee configuration of the leg, so a leg never flips between solutions
+// from one interpolation step to the next.
+func solveEffectorIK(leg *Leg, effectorTarget Coordinate, debugChannel chan string) (ServoAngles, error) {
+	// The closed form solution only exists for standard coxa, femur and tibia legs
+	if !leg.Chain.IsStandard() {
+		return solveEffectorIKNumerically(leg, effectorTarget)
+	}
+
+	solutions, err := solveEffectorIKBranches(leg, effectorTarget, debugChannel)
+	servoAngles := solutions[leg.Knee]
+	if err != nil {
+		return servoAngles, err
+	}
+
+	// A solution is only valid if the servos are able to reach it
+	err = leg.JointLimits.Check(leg.Index, servoAngles)
+	if err != nil {
+		return servoAngles, fmt.Errorf("[IK Solver] ERROR: %w", err)
+	}
+
+	return servoAngles, nil
+}
+
+// solveEffectorIKBranches solves the leg IK equations for an end effector target given in the body reference frame
+// and returns both the knee up and the knee down solution
+func solveEffectorIKBranches(leg *Leg, effectorTarget Coordinate, debugChannel chan string) ([2]ServoAngles, error) {
 
+	var solutions [2]ServoAngles
 	var servoAngles ServoAngles
+
+	if !leg.Chain.IsStandard() {
+		return solutions, fmt.Errorf("[IK Solver] ERROR: Leg %d is not a coxa, femur and tibia leg. No closed form solution available.", leg.Index)
+	}
+
+	coxaOrigin := leg.GetJointOrigin(leg.OffsetTransformationMatrix)
+
 	// Inverse kinematics equation 1 (ref readme.md)
-	x := effectorTarget.X - leg.Joints[COXA_ORIGIN_INDEX].X
-	y := effectorTarget.Y - leg.Joints[COXA_ORIGIN_INDEX].Y
+	x := effectorTarget.X - coxaOrigin.X
+	y := effectorTarget.Y - coxaOrigin.Y
 
 	// servoAngles.Coxa = (180.0/math.Pi)*math.Atan2(y, x) + 360.0 - leg.CoxaSeparationAngle*float64(leg.Index)
 	servoAngles.Coxa = (180.0/math.Pi)*math.Atan2(y, x) + 360.0 - leg.CoxaSeparationAngle
@@ -37,18 +117,19 @@ func SolveEffectorIK(leg *Leg, effectorTarget Coordinate, debugChannel chan stri
 	}
 
 	// Inverse kinematics equation 2 (ref readme.md)
-	dx := effectorTarget.X - leg.Joints[COXA_ORIGIN_INDEX].X
-	dy := effectorTarget.Y - leg.Joints[COXA_ORIGIN_INDEX].Y
+	dx := effectorTarget.X - coxaOrigin.X
+	dy := effectorTarget.Y - coxaOrigin.Y
 
+	// The coxa rotates around Z, so the femur origin is located in the same XY plane as the coxa origin
 	L1 := math.Sqrt((dx*dx + dy*dy)) - leg.SegmentLengths.Coxa
-	L2 := effectorTarget.Z - leg.Joints[FEMUR_ORIGIN_INDEX].Z
+	L2 := effectorTarget.Z - coxaOrigin.Z
 	L := math.Sqrt(L2*L2 + L1*L1)
 
 	// Inverse kinematics equation 3 (ref readme.md)
 	alpha_1 := math.Acos(L2 / L)
 
 	if math.IsNaN(alpha_1) {
-		return servoAngles, fmt.Errorf("[IK Solver] ERROR: Unable to find a solution. Target is too far away.")
+		return solutions, fmt.Errorf("[IK Solver] ERROR: Unable to find a solution. Target is too far away.")
 	}
 
 	// Inverse kinematics equation 4 (ref readme.md)
@@ -59,7 +140,7 @@ func SolveEffectorIK(leg *Leg, effectorTarget Coordinate, debugChannel chan stri
 			(-2 * leg.SegmentLengths.Femur * L))
 
 	if math.IsNaN(alpha_2) {
-		return servoAngles, fmt.Errorf("[IK Solver] ERROR: Unable to find a solution. Target is too far away.")
+		return solutions, fmt.Errorf("[IK Solver] ERROR: Unable to find a solution. Target is too far away.")
 	}
 
 	// Inverse kinematics equation 5 (ref readme.md)
@@ -68,5 +149,82 @@ func SolveEffectorIK(leg *Leg, effectorTarget Coordinate, debugChannel chan stri
 	// Inverse kinematics equation 6 (ref readme.md)
 	servoAngles.Tibia = 180 - (180.0/math.Pi)*math.Acos((L*L-leg.SegmentLengths.Femur*leg.SegmentLengths.Femur-leg.SegmentLengths.Tibia*leg.SegmentLengths.Tibia)/(-2*leg.SegmentLengths.Tibia*leg.SegmentLengths.Femur))
 
-	return servoAngles, nil
+	solutions[KneeUp] = servoAngles
+
+	// The knee down solution mirrors the femur and tibia around the line
+	// from the femur origin to the end effector
+	solutions[KneeDown] = NewServoAngles(
+		servoAngles.Coxa,
+		90-(180.0/math.Pi)*(alpha_1-alpha_2),
+		-servoAngles.Tibia)
+
+	return solutions, nil
+}
+
+// solveEffectorIKNumerically solves the IK equations for legs with any kinematic chain (end effector
+// target given in the body reference frame). This is a damped least squares solver:
+//
+//	delta_angles = J^T x (J x J^T + lambda^2 x I)^-1 x error
+//
+// where J is the 3xN Jacobian of the end effector position with respect to the joint angles.
+// The iteration starts from the current angles of the leg, so consecutive targets result in
+// consecutive solutions (a leg will not flip to a different solution mid-stride).
+// Joint angles are kept within the joint limits of the leg during the iteration.
+func solveEffectorIKNumerically(leg *Leg, effectorTarget Coordinate) (ServoAngles, error) {
+	n := len(leg.Chain)
+	angles := leg.ServoAngles.Values(n)
+
+	for iteration := 0; iteration < DLS_MAX_ITERATIONS; iteration++ {
+		frames := leg.Chain.Frames(leg.OffsetTransformationMatrix, angles)
+		effector := leg.GetJointOrigin(frames[n])
+
+		e := mat.NewVecDense(3, []float64{
+			effectorTarget.X - effector.X,
+			effectorTarget.Y - effector.Y,
+			effectorTarget.Z - effector.Z,
+		})
+
+		if mat.Norm(e, 2) < DLS_TOLERANCE {
+			servoAngles := NewServoAnglesFromValues(angles)
+			err := leg.JointLimits.Check(leg.Index, servoAngles)
+			if err != nil {
+				return servoAngles, fmt.Errorf("[IK Solver] ERROR: %w", err)
+			}
+			return servoAngles, nil
+		}
+
+		// All joints are revolute joints rotating around the Z axis of the previous frame.
+		// Column i in the Jacobian is then z_i x (effector - origin_i)
+		J := mat.NewDense(3, n, nil)
+		for i := 0; i < n; i++ {
+			z := []float64{frames[i].At(0, 2), frames[i].At(1, 2), frames[i].At(2, 2)}
+			o := leg.GetJointOrigin(frames[i])
+			r := []float64{effector.X - o.X, effector.Y - o.Y, effector.Z - o.Z}
+			J.Set(0, i, z[1]*r[2]-z[2]*r[1])
+			J.Set(1, i, z[2]*r[0]-z[0]*r[2])
+			J.Set(2, i, z[0]*r[1]-z[1]*r[0])
+		}
+
+		var A mat.Dense
+		A.Mul(J, J.T())
+		for i := 0; i < 3; i++ {
+			A.Set(i, i, A.At(i, i)+DLS_DAMPING*DLS_DAMPING)
+		}
+
+		var y mat.VecDense
+		err := y.SolveVec(&A, e)
+		if err != nil {
+			return ServoAngles{}, fmt.Errorf("[IK Solver] ERROR: Unable to find a solution (%w)", err)
+		}
+
+		var delta mat.VecDense
+		delta.MulVec(J.T(), &y)
+
+		for i := 0; i < n; i++ {
+			step := math.Max(-DLS_MAX_STEP, math.Min(DLS_MAX_STEP, delta.AtVec(i)*180.0/math.Pi))
+			angles[i] = leg.JointLimits.Get(i).Clamp(angles[i] + step)
+		}
+	}
+
+	return ServoAngles{}, fmt.Errorf("[IK Solver] ERROR: Unable to find a solution. Target is out of reach for leg %d.", leg.Index)
 }
@@ -0,0 +1,155 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+	"testing"
+)
+
+// The body moves while every foot stays where it is
+func TestSolveBodyIK(t *testing.T) {
+	tests := []struct {
+		name        string
+		rotation    Coordinate
+		translation Coordinate
+	}{
+		{"pitch", Coordinate{Y: 10}, Coordinate{}},
+		{"roll", Coordinate{X: -10}, Coordinate{}},
+		{"yaw", Coordinate{Z: 15}, Coordinate{}},
+		{"up", Coordinate{}, Coordinate{Z: -10}},
+		{"down", Coordinate{}, Coordinate{Z: 10}},
+		{"shift", Coordinate{}, Coordinate{X: 10, Y: -5}},
+		{"combined", Coordinate{X: 5, Y: -5, Z: 5}, Coordinate{X: 5, Z: 5}},
+	}
+
+	for _, test := range tests {
+		p := NewPod(NewExampleHexapod1())
+		feet := p.GetEndEffectorPositions()
+
+		angles, err := SolveBodyIK(p.Legs, test.rotation, test.translation, nil)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		for i, l := range p.Legs {
+			l.BodyPose = NewBodyPose(test.rotation, test.translation)
+			l.RecalculateForwardKinematics(angles[i])
+			if !closeTo(l.Effector(), feet[i], 1e-6) {
+				t.Errorf("%s: leg %d moved from %+v to %+v", test.name, i, feet[i], l.Effector())
+			}
+		}
+	}
+
+	// A pose out of reach is rejected
+	p := NewPod(NewExampleHexapod1())
+	if _, err := SolveBodyIK(p.Legs, Coordinate{}, Coordinate{Z: -500}, nil); err == nil {
+		t.Error("no error raising the body 500 mm")
+	}
+}
+
+// Both IK solutions move the end effector to the target: FK(IK(x)) == x
+func TestSolveEffectorIKBranches(t *testing.T) {
+	offsets := []Coordinate{{}, {X: 20}, {Y: -20}, {X: -15, Y: 15, Z: -10}, {Z: 20}}
+
+	for _, body := range []func() *BodyDefinition{NewExampleHexapod1, NewSpider} {
+		p := NewPod(body())
+		for _, l := range p.Legs {
+			for _, offset := range offsets {
+				neutral := l.NeutralEffectorCoordinate
+				target := Coordinate{neutral.X + offset.X, neutral.Y + offset.Y, neutral.Z + offset.Z}
+				solutions, err := SolveEffectorIKBranches(l, target, nil)
+				if err != nil {
+					t.Errorf("leg %d, target %+v: %v", l.Index, target, err)
+					continue
+				}
+				if solutions[KneeUp].Femur == solutions[KneeDown].Femur {
+					t.Errorf("leg %d, target %+v: the knee up and knee down solutions are the same", l.Index, target)
+				}
+				for _, knee := range []KneeConfiguration{KneeUp, KneeDown} {
+					l.RecalculateForwardKinematics(solutions[knee])
+					if !closeTo(l.Effector(), target, 1e-6) {
+						t.Errorf("leg %d, knee %s: FK(IK(%+v)) = %+v", l.Index, knee, target, l.Effector())
+					}
+				}
+			}
+		}
+	}
+
+	p := NewPod(NewExampleHexapod1())
+	if _, err := SolveEffectorIKBranches(p.Legs[0], Coordinate{X: 1000}, nil); err == nil {
+		t.Error("no error for a target out of reach")
+	}
+}
+
+// A leg keeps its knee configuration, so it never flips between solutions mid-stride
+func TestKneeConfiguration(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	neutral := p.Legs[2].NeutralEffectorCoordinate
+	if err := p.SetKneeConfiguration(2, KneeDown); err != nil {
+		t.Fatal(err)
+	}
+	l := p.Legs[2]
+	if !closeTo(l.NeutralEffectorCoordinate, neutral, 1e-6) {
+		t.Errorf("the neutral position moved from %+v to %+v", neutral, l.NeutralEffectorCoordinate)
+	}
+
+	if err := p.SetStrideVector(1, 20, 0); err != nil {
+		t.Fatal(err)
+	}
+	for i, target := range l.IntermediateEffectorCoordinates {
+		solutions, err := SolveEffectorIKBranches(l, target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		angles, want := l.IntermediateAngles[i], solutions[KneeDown]
+		if math.Abs(angles.Femur-want.Femur) > 1e-6 || math.Abs(angles.Tibia-want.Tibia) > 1e-6 {
+			t.Errorf("step %d: angles %+v, want the knee down solution %+v", i, l.IntermediateAngles[i], solutions[KneeDown])
+		}
+	}
+}
+
+// Legs with a kinematic chain are solved numerically, for any number of joints: FK(IK(x)) == x
+func TestSolveEffectorIKNumerically(t *testing.T) {
+	offsets := [][]float64{{0, 0, 0, 0}, {10, 0, 0, 0}, {0, -10, 0, 0}, {-5, 5, 10, 0}, {0, 5, -5, 10}}
+
+	for _, body := range []func() *BodyDefinition{NewTarsusHexapod, NewTwoJointHexapod} {
+		p := NewPod(body())
+		// The targets are reached by the legs of a second pod, so they are within the workspace
+		reference := NewPod(body())
+		for i, l := range p.Legs {
+			r := reference.Legs[i]
+			n := len(r.Chain)
+			for _, offset := range offsets {
+				values := r.ServoAngles.Values(n)
+				for j := range values {
+					values[j] += offset[j]
+				}
+				r.RecalculateForwardKinematics(NewServoAnglesFromValues(values))
+				target := r.Effector()
+
+				angles, err := SolveEffectorIK(l, target, nil)
+				if err != nil {
+					t.Errorf("%d joints, leg %d, target %+v: %v", n, l.Index, target, err)
+					continue
+				}
+				l.RecalculateForwardKinematics(angles)
+				if !closeTo(l.Effector(), target, DLS_TOLERANCE) {
+					t.Errorf("%d joints, leg %d: FK(IK(%+v)) = %+v", n, l.Index, target, l.Effector())
+				}
+			}
+		}
+	}
+}
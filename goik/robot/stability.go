@@ -0,0 +1,194 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+	"sort"
+)
+
+// Stability describes the static stability of the pod at a given moment.
+// All coordinates are in the base reference frame, and the stability is
+// evaluated in the XY plane (the ground plane).
+type Stability struct {
+	// Index of every leg in the stance phase (end effector on the ground)
+	StanceLegs []int
+	// The support polygon is the convex hull of the grounded end effectors (counter clockwise)
+	SupportPolygon []Coordinate
+	// Centre of mass of the pod
+	CenterOfMass Coordinate
+	// The static stability margin is the shortest distance from the centre of mass (projected
+	// onto the ground) to the edges of the support polygon. The margin is negative if the
+	// centre of mass is outside the support polygon (the pod would fall over)
+	Margin float64
+}
+
+// IsStable returns true if the centre of mass is inside the support polygon
+func (s Stability) IsStable() bool {
+	return s.Margin > 0
+}
+
+// StabilityPrediction is the worst case static stability during a gait cycle
+type StabilityPrediction struct {
+	// The lowest stability margin in the cycle
+	Stability Stability
+	// Gait cycle phase (0-1) where the lowest margin occurs
+	Phase float64
+	// Number of ticks into the simulated cycles where the lowest margin occurs
+	Tick int
+}
+
+// isGrounded returns true if the end effector of a leg is on the ground
+func (p *Pod) isGrounded(legIndex int) bool {
+	return !p.IsWalking() || !p.IsSwingPhase(legIndex)
+}
+
+// CalculateStability calculates the support polygon from the legs currently in the stance
+// phase and the static stability margin of the pod
+func (p *Pod) CalculateStability() Stability {
+	var s Stability
+	var feet []Coordinate
+	for i, l := range p.Legs {
+		if p.isGrounded(i) {
+			s.StanceLegs = append(s.StanceLegs, i)
+			feet = append(feet, l.Effector())
+		}
+	}
+
+	s.SupportPolygon = convexHull(feet)
+	s.CenterOfMass = p.CenterOfMass()
+	s.Margin = stabilityMargin(s.SupportPolygon, s.CenterOfMass)
+	return s
+}
+
+// PredictStability runs a copy of the pod through two full gait cycles using the current
+// gait (or the gait it is changing to), stride and body pose, and returns the lowest static stability margin encountered.
+// The pod itself is left untouched.
+func (p *Pod) PredictStability() StabilityPrediction {
+	sim := p.simulationCopy()
+
+	prediction := StabilityPrediction{Stability: sim.CalculateStability(), Phase: sim.CyclePhase}
+	ticks := sim.simulationTicks()
+	for tick := 1; tick <= ticks; tick++ {
+		sim.UpdateMovement()
+		s := sim.CalculateStability()
+		if s.Margin < prediction.Stability.Margin {
+			prediction = StabilityPrediction{Stability: s, Phase: sim.CyclePhase, Tick: tick}
+		}
+	}
+	return prediction
+}
+
+// simulationCopy returns a copy of the pod that can walk through a gait cycle without
+// modifying the pod itself. The copy walks indefinitely and does not record any motion.
+func (p *Pod) simulationCopy() *Pod {
+	sim := *p
+	sim.IsRecording = false
+	sim.state = Walking
+	// The copy must not emit events to the listeners of the pod
+	sim.eventListeners = nil
+	sim.targetGaitCycles = 0
+	// The copy may switch gait without changing the gait of the pod
+	definition := *p.BodyDefinition
+	sim.BodyDefinition = &definition
+	sim.Legs = make([]*Leg, len(p.Legs))
+	for i, l := range p.Legs {
+		leg := *l
+		// The simulated legs need their own joint locations
+		leg.Joints = nil
+		leg.RecalculateForwardKinematics(l.ServoAngles)
+		sim.Legs[i] = &leg
+	}
+	return &sim
+}
+
+// convexHull returns the convex hull (in the XY plane) of a set of points
+// ordered counter clockwise (Andrew's monotone chain algorithm)
+func convexHull(points []Coordinate) []Coordinate {
+	if len(points) < 3 {
+		return append([]Coordinate{}, points...)
+	}
+
+	sorted := append([]Coordinate{}, points...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].X == sorted[j].X {
+			return sorted[i].Y < sorted[j].Y
+		}
+		return sorted[i].X < sorted[j].X
+	})
+
+	cross := func(o, a, b Coordinate) float64 {
+		return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+	}
+
+	var hull []Coordinate
+	// Lower hull
+	for _, c := range sorted {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], c) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, c)
+	}
+	// Upper hull
+	lower := len(hull) + 1
+	for i := len(sorted) - 2; i >= 0; i-- {
+		c := sorted[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], c) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, c)
+	}
+	return hull[:len(hull)-1]
+}
+
+// distanceToSegment returns the distance (in the XY plane) from c to the line segment a-b
+func distanceToSegment(c, a, b Coordinate) float64 {
+	dx := b.X - a.X
+	dy := b.Y - a.Y
+	t := 0.0
+	if dx != 0 || dy != 0 {
+		t = math.Max(0, math.Min(1, ((c.X-a.X)*dx+(c.Y-a.Y)*dy)/(dx*dx+dy*dy)))
+	}
+	return math.Hypot(c.X-(a.X+t*dx), c.Y-(a.Y+t*dy))
+}
+
+// stabilityMargin returns the signed distance (in the XY plane) from c to the edges
+// of a counter clockwise convex polygon. The distance is negative if c is outside the polygon.
+// A polygon with less than 3 corners has no area, so the margin is never positive.
+func stabilityMargin(polygon []Coordinate, c Coordinate) float64 {
+	if len(polygon) == 0 {
+		return math.Inf(-1)
+	}
+	if len(polygon) == 1 {
+		return -math.Hypot(c.X-polygon[0].X, c.Y-polygon[0].Y)
+	}
+
+	inside := len(polygon) >= 3
+	distance := math.Inf(1)
+	for i := range polygon {
+		a := polygon[i]
+		b := polygon[(i+1)%len(polygon)]
+		distance = math.Min(distance, distanceToSegment(c, a, b))
+		// c must be on the left side of every edge of a counter clockwise polygon
+		if (b.X-a.X)*(c.Y-a.Y)-(b.Y-a.Y)*(c.X-a.X) < 0 {
+			inside = false
+		}
+	}
+
+	if inside {
+		return distance
+	}
+	return -distance
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestConvexHull(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []Coordinate
+		hull   []Coordinate
+	}{
+		{"square with a point inside", []Coordinate{{X: 1, Y: 1}, {X: 0, Y: 0}, {X: 2, Y: 2}, {X: 0, Y: 2}, {X: 2, Y: 0}},
+			[]Coordinate{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 2}, {X: 0, Y: 2}}},
+		{"point on an edge", []Coordinate{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}, {X: 1, Y: 2}},
+			[]Coordinate{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 1, Y: 2}}},
+		{"two points", []Coordinate{{X: 0, Y: 0}, {X: 1, Y: 1}}, []Coordinate{{X: 0, Y: 0}, {X: 1, Y: 1}}},
+	}
+
+	for _, test := range tests {
+		if hull := convexHull(test.points); !reflect.DeepEqual(hull, test.hull) {
+			t.Errorf("%s: hull %v, want %v", test.name, hull, test.hull)
+		}
+	}
+}
+
+func TestStabilityMargin(t *testing.T) {
+	square := []Coordinate{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 4}, {X: 0, Y: 4}}
+	tests := []struct {
+		name    string
+		polygon []Coordinate
+		c       Coordinate
+		margin  float64
+	}{
+		{"centre", square, Coordinate{X: 2, Y: 2}, 2},
+		{"near an edge", square, Coordinate{X: 3, Y: 2}, 1},
+		{"outside", square, Coordinate{X: 7, Y: 2}, -3},
+		{"outside a corner", square, Coordinate{X: 7, Y: 8}, -5},
+		{"on an edge", square, Coordinate{X: 4, Y: 2}, 0},
+		{"line", []Coordinate{{X: 0, Y: 0}, {X: 4, Y: 0}}, Coordinate{X: 2, Y: 0}, 0},
+		{"point", []Coordinate{{X: 0, Y: 0}}, Coordinate{X: 3, Y: 4}, -5},
+		{"no feet", nil, Coordinate{}, math.Inf(-1)},
+	}
+
+	for _, test := range tests {
+		if margin := stabilityMargin(test.polygon, test.c); margin != test.margin {
+			t.Errorf("%s: margin %2.2f, want %2.2f", test.name, margin, test.margin)
+		}
+	}
+}
+
+// A pod standing on all legs is stable, and remains stable walking with a tripod gait
+func TestCalculateStability(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	s := p.CalculateStability()
+	if len(s.StanceLegs) != len(p.Legs) || len(s.SupportPolygon) != len(p.Legs) {
+		t.Errorf("%d stance legs and %d corners, want %d", len(s.StanceLegs), len(s.SupportPolygon), len(p.Legs))
+	}
+	if !s.IsStable() {
+		t.Errorf("a standing pod is unstable (margin %2.2f)", s.Margin)
+	}
+
+	if err := p.SetStrideVector(1, 20, 0); err != nil {
+		t.Fatal(err)
+	}
+	ticks := p.Clock.Ticks
+	prediction := p.PredictStability()
+	if p.Clock.Ticks != ticks || p.IsWalking() {
+		t.Error("predicting the stability moved the pod")
+	}
+	if !prediction.Stability.IsStable() || prediction.Stability.Margin > s.Margin {
+		t.Errorf("the lowest margin walking is %2.2f, standing %2.2f", prediction.Stability.Margin, s.Margin)
+	}
+	if len(prediction.Stability.StanceLegs) != 3 {
+		t.Errorf("%d legs on the ground with the lowest margin, want 3", len(prediction.Stability.StanceLegs))
+	}
+}
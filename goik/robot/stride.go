@@ -0,0 +1,192 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"fmt"
+	"math"
+)
+
+// Parameters for the maximum stride search
+const (
+	// Upper bound (mm) for the length of a stride vector
+	MAX_STRIDE_SEARCH_LENGTH = 500.0
+	// Upper bound (degrees) for a stride rotation
+	MAX_STRIDE_SEARCH_ANGLE = 360.0
+	// The search stops when the stride is known with this precision (mm or degrees)
+	MAX_STRIDE_SEARCH_TOLERANCE = 0.1
+)
+
+// strideVectorPath returns the end effector path (with a given number of steps) for a stride
+// along the vector (x, y) centered on the end effector location c
+func strideVectorPath(c Coordinate, x float64, y float64, steps int) IntermediateEffectorCoordinates {
+	path := make(IntermediateEffectorCoordinates, steps)
+
+	xMin := c.X - x
+	yMin := c.Y - y
+	xStep := 2 * x / float64(steps-1)
+	yStep := 2 * y / float64(steps-1)
+
+	for i := 0; i < steps; i++ {
+		path[i] = NewCoordinate(xMin+float64(i)*xStep, yMin+float64(i)*yStep, POD_Z_HEIGHT)
+	}
+	return path
+}
+
+// rotationPath returns the end effector path (with a given number of steps) for a stride rotating the
+// body around its center. The path is centered on the end effector location c
+func rotationPath(c Coordinate, degrees float64, steps int) IntermediateEffectorCoordinates {
+	path := make(IntermediateEffectorCoordinates, steps)
+
+	radius := math.Sqrt(c.X*c.X + c.Y*c.Y)
+	stepRadians := (degrees * math.Pi / 360) / float64(steps-1)
+	angle := math.Atan2(c.Y, c.X) - 0.5*degrees*math.Pi/360
+
+	for i := 0; i < steps; i++ {
+		delta := float64(i) * stepRadians
+		path[i] = NewCoordinate(radius*math.Cos(angle+delta), radius*math.Sin(angle+delta), POD_Z_HEIGHT)
+	}
+	return path
+}
+
+// StrideLimit is the result of a maximum stride search
+type StrideLimit struct {
+	// The largest stride (length of the stride vector in mm, or the rotation in degrees)
+	// every leg is able to complete
+	Stride float64
+	// Index of the leg limiting the stride (-1 if the stride is only limited by the search range)
+	Leg int
+	// The reason the limiting leg is unable to complete a longer stride
+	Err error
+}
+
+// checkStridePath verifies that a leg is able to follow a stride path through the swing phase
+// (lifted by the gait's lift height) and back through the stance phase at the speed of a gait.
+// Every tick of the step cycle must solve within the joint limits of the leg.
+func (p *Pod) checkStridePath(leg *Leg, path IntermediateEffectorCoordinates, gait *Gait) error {
+	// Solving the IK equations for a copy of the leg leaves the leg itself untouched,
+	// while the numerical solver can still start from the previous solution
+	probe := *leg
+
+	for i, target := range strideCycle(path, gait, leg.Index) {
+		angles, err := SolveEffectorIK(&probe, target, p.debugChannel)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			err = leg.JointLimits.CheckSpeed(leg.Index, probe.ServoAngles, angles, gait.Dt())
+			if err != nil {
+				return err
+			}
+		}
+		probe.ServoAngles = angles
+	}
+	return nil
+}
+
+// checkStride returns the index of the first leg unable to complete a stride
+// with a gait (-1 if all legs are able to)
+func (p *Pod) checkStride(path func(leg *Leg) IntermediateEffectorCoordinates, gait *Gait) (int, error) {
+	for _, leg := range p.Legs {
+		err := p.checkStridePath(leg, path(leg), gait)
+		if err != nil {
+			return leg.Index, err
+		}
+	}
+	return -1, nil
+}
+
+// maxStride binary searches for the largest stride in [0, upper] all legs are able to complete
+func (p *Pod) maxStride(upper float64, path func(stride float64, leg *Leg) IntermediateEffectorCoordinates) (StrideLimit, error) {
+	err := p.NextGait().Validate(len(p.Legs))
+	if err != nil {
+		return StrideLimit{}, err
+	}
+
+	pathFor := func(stride float64) func(leg *Leg) IntermediateEffectorCoordinates {
+		return func(leg *Leg) IntermediateEffectorCoordinates { return path(stride, leg) }
+	}
+
+	legIndex, err := p.checkStride(pathFor(0), p.NextGait())
+	if err != nil {
+		return StrideLimit{Leg: legIndex, Err: err}, fmt.Errorf("leg %d is unable to step in place: %w", legIndex, err)
+	}
+
+	legIndex, err = p.checkStride(pathFor(upper), p.NextGait())
+	if err == nil {
+		return StrideLimit{Stride: upper, Leg: -1}, nil
+	}
+	limit := StrideLimit{Leg: legIndex, Err: err}
+
+	low := 0.0
+	high := upper
+	for high-low > MAX_STRIDE_SEARCH_TOLERANCE {
+		stride := (low + high) / 2
+		legIndex, err := p.checkStride(pathFor(stride), p.NextGait())
+		if err == nil {
+			low = stride
+		} else {
+			high = stride
+			limit.Leg = legIndex
+			limit.Err = err
+		}
+	}
+	limit.Stride = low
+
+	return limit, nil
+}
+
+// neutralEffector returns the location of a leg's end effector in the neutral stance
+// (strides are always planned from the neutral stance)
+func neutralEffector(leg *Leg) Coordinate {
+	return NewCoordinate(leg.NeutralEffectorCoordinate.X, leg.NeutralEffectorCoordinate.Y, POD_Z_HEIGHT)
+}
+
+// MaxStrideVector finds the longest stride vector in the direction (x, y) that every leg is able
+// to complete using the current gait (and its lift height), body pose and joint limits.
+// The returned stride is the length of the vector to use with SetStrideVector.
+func (p *Pod) MaxStrideVector(x float64, y float64) (StrideLimit, error) {
+	length := math.Hypot(x, y)
+	if length == 0 {
+		return StrideLimit{}, fmt.Errorf("the stride direction must be a non zero vector")
+	}
+
+	return p.maxStride(MAX_STRIDE_SEARCH_LENGTH, func(stride float64, leg *Leg) IntermediateEffectorCoordinates {
+		return strideVectorPath(neutralEffector(leg), stride*x/length, stride*y/length, p.InterpolationSteps)
+	})
+}
+
+// MaxStrideRotation finds the largest rotation (in degrees) that every leg is able to complete
+// using the current gait (and its lift height), body pose and joint limits. The sign of direction selects
+// the direction of the rotation, and the returned stride has the same sign.
+func (p *Pod) MaxStrideRotation(direction float64) (StrideLimit, error) {
+	sign := 1.0
+	if direction < 0 {
+		sign = -1.0
+	}
+
+	limit, err := p.maxStride(MAX_STRIDE_SEARCH_ANGLE, func(stride float64, leg *Leg) IntermediateEffectorCoordinates {
+		return rotationPath(neutralEffector(leg), sign*stride, p.InterpolationSteps)
+	})
+	limit.Stride *= sign
+	return limit, err
+}
+
+// StrideForSpeed returns the length (mm) of the stride vector needed for walking at a speed
+// (mm/s) with a gait. The stride path is twice as long as the stride vector, and the
+// body moves the length of the stride path during the stance phase.
+func StrideForSpeed(gait *Gait, speed float64) float64 {
+	return speed * gait.StanceDuration() / 2
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import "math"
+
+// StrideMotion describes a stride as the planar rigid body motion of the body during the stance phase.
+// The body turns Degrees around the pivot point, while the pivot point moves (X, Y) mm. All coordinates
+// are in the base reference frame at the start of the stride.
+//
+// A translation without rotation walks the pod in a straight line, a rotation around the body origin turns
+// the pod on the spot, and a rotation around a pivot away from the body walks the pod along an arc around the pivot.
+// Combining translation and rotation walks the pod sideways (crab walk) while it turns.
+type StrideMotion struct {
+	// Movement (mm) of the pivot point
+	X float64
+	Y float64
+	// Rotation (degrees) of the body. Positive angles turn the body the same way as a positive SetRotation
+	Degrees float64
+	// Pivot point of the rotation
+	Pivot Coordinate
+}
+
+// twist returns the translation (mm) and rotation (radians) of a constant velocity and yaw rate
+// that moves the body through the stride motion (see twistPath)
+func (m StrideMotion) twist() (float64, float64, float64) {
+	radians := m.Degrees * math.Pi / 180
+	sin := math.Sin(radians)
+	cos := math.Cos(radians)
+
+	// Displacement of the body origin. The pivot point ends up at the pivot + (X, Y)
+	dx := m.X + m.Pivot.X - (cos*m.Pivot.X - sin*m.Pivot.Y)
+	dy := m.Y + m.Pivot.Y - (sin*m.Pivot.X + cos*m.Pivot.Y)
+	if radians == 0 {
+		return dx, dy, 0
+	}
+
+	// The displacement of the body origin integrates the velocity rotating with the body.
+	// Solve for the velocity by inverting the integral
+	a := sin / radians
+	b := (1 - cos) / radians
+	det := a*a + b*b
+	return (a*dx + b*dy) / det, (a*dy - b*dx) / det, radians
+}
+
+// strideMotionPath returns the end effector path (with a given number of steps) for a stride motion,
+// centered on the end effector location c
+func strideMotionPath(c Coordinate, m StrideMotion, steps int) IntermediateEffectorCoordinates {
+	x, y, radians := m.twist()
+	return twistPath(c, x, y, radians, steps)
+}
+
+// SetStrideMotion is similar to SetStrideVector and SetRotation, but the stride is a combination of
+// translation and rotation around a pivot point (see StrideMotion). Every end effector follows
+// the path it describes in the body frame while the body moves.
+func (p *Pod) SetStrideMotion(nrepeats int, m StrideMotion) error {
+	err := p.checkStrideChange()
+	if err != nil {
+		return err
+	}
+	err = p.setStridePaths(func(leg *Leg) IntermediateEffectorCoordinates {
+		return strideMotionPath(neutralEffector(leg), m, p.InterpolationSteps)
+	})
+	if err != nil {
+		return err
+	}
+	p.setTargetGaitCycles(nrepeats)
+	p.Velocity = nil
+	p.StridePivot = nil
+	if m.Degrees != 0 {
+		p.StridePivot = &Coordinate{X: m.Pivot.X, Y: m.Pivot.Y}
+	}
+
+	p.defineStride()
+	return nil
+}
+
+// SetRotationAround is similar to SetRotation, but the body turns around a pivot point (body frame)
+// instead of the body origin. The pod can pivot around one of its feet, or circle around an object.
+func (p *Pod) SetRotationAround(nrepeats int, degrees float64, pivot Coordinate) error {
+	c := p.BodyPose.ToBaseFrame(pivot)
+	// A rotation stride (see rotationPath) turns the body half the stride angle during the stance phase
+	return p.SetStrideMotion(nrepeats, StrideMotion{Degrees: degrees / 2, Pivot: NewCoordinate(c.X, c.Y, 0)})
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+	"testing"
+)
+
+// The twist of a stride motion moves the body through the motion: a foot on the ground at the
+// start of the stance phase is at the same place on the ground at the end of it
+func TestStrideMotionTwist(t *testing.T) {
+	tests := []struct {
+		name   string
+		motion StrideMotion
+	}{
+		{"straight", StrideMotion{X: 20, Y: -10}},
+		{"turn on the spot", StrideMotion{Degrees: 10}},
+		{"arc", StrideMotion{Degrees: 8, Pivot: Coordinate{Y: 300}}},
+		{"crab walk while turning", StrideMotion{X: 15, Y: 10, Degrees: -6, Pivot: Coordinate{X: 40, Y: -20}}},
+	}
+
+	c := NewCoordinate(120, 40, POD_Z_HEIGHT)
+	for _, test := range tests {
+		m := test.motion
+		path := strideMotionPath(c, m, 21)
+		// The stance phase follows the path from the end to the start
+		start, end := path[len(path)-1], path[0]
+
+		// Where the foot is at the end of the stride, in the body frame at the start of it
+		radians := m.Degrees * math.Pi / 180
+		q := NewCoordinate(end.X-m.Pivot.X, end.Y-m.Pivot.Y, 0)
+		ground := NewCoordinate(
+			math.Cos(radians)*q.X-math.Sin(radians)*q.Y+m.Pivot.X+m.X,
+			math.Sin(radians)*q.X+math.Cos(radians)*q.Y+m.Pivot.Y+m.Y,
+			POD_Z_HEIGHT)
+		if !closeTo(ground, start, 1e-9) {
+			t.Errorf("%s: the foot slides from %+v to %+v", test.name, start, ground)
+		}
+	}
+}
+
+// A rotation around the body origin is the rotation stride
+func TestStrideMotionRotation(t *testing.T) {
+	c := NewCoordinate(120, 40, POD_Z_HEIGHT)
+	motion := strideMotionPath(c, StrideMotion{Degrees: 10}, 9)
+	rotation := rotationPath(c, 20, 9)
+	for i := range motion {
+		if !closeTo(motion[i], rotation[i], 1e-9) {
+			t.Fatalf("stride motion path %v, want the rotation path %v", motion, rotation)
+		}
+	}
+}
+
+func TestSetRotationAround(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	// Turn around the foot of leg 0, which then stays in place
+	pivot := p.Legs[0].NeutralEffectorCoordinate
+	if err := p.SetRotationAround(1, 10, pivot); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range p.Legs[0].IntermediateEffectorCoordinates {
+		if math.Hypot(c.X-pivot.X, c.Y-pivot.Y) > 1e-6 {
+			t.Errorf("the pivot foot moves to %+v", c)
+			break
+		}
+	}
+	if p.StridePivot == nil || p.StridePivot.X != pivot.X || p.StridePivot.Y != pivot.Y {
+		t.Errorf("stride pivot %v, want %+v", p.StridePivot, pivot)
+	}
+}
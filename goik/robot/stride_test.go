@@ -0,0 +1,115 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStridePaths(t *testing.T) {
+	c := NewCoordinate(100, 50, POD_Z_HEIGHT)
+
+	path := strideVectorPath(c, 20, -10, 5)
+	if !closeTo(path[0], NewCoordinate(80, 60, POD_Z_HEIGHT), 1e-9) || !closeTo(path[4], NewCoordinate(120, 40, POD_Z_HEIGHT), 1e-9) ||
+		!closeTo(path[2], c, 1e-9) {
+		t.Errorf("stride vector path %v", path)
+	}
+
+	path = rotationPath(c, 20, 5)
+	radius := math.Hypot(c.X, c.Y)
+	for _, p := range path {
+		if math.Abs(math.Hypot(p.X, p.Y)-radius) > 1e-9 {
+			t.Errorf("rotation path %v leaves the circle through %+v", path, c)
+			break
+		}
+	}
+	if !closeTo(path[2], c, 1e-9) {
+		t.Errorf("rotation path %v is not centered on %+v", path, c)
+	}
+	// The path covers half the rotation, since the stride is taken back and forth
+	swept := math.Atan2(path[4].Y, path[4].X) - math.Atan2(path[0].Y, path[0].X)
+	if math.Abs(swept*180/math.Pi-10) > 1e-9 {
+		t.Errorf("the rotation path sweeps %2.2f degrees, want 10", swept*180/math.Pi)
+	}
+}
+
+// The maximum stride is within reach of every leg, and a slightly longer stride is not
+func TestMaxStride(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	// Limit the coxa joints, so that the rotation is limited as well
+	limits := NewXL320JointLimits()
+	limits.Coxa = JointLimit{Min: -30, Max: 30}
+	for l := range p.Legs {
+		if err := p.SetJointLimits(l, limits); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		limit func() (StrideLimit, error)
+		path  func(stride float64) func(leg *Leg) IntermediateEffectorCoordinates
+	}{
+		{"vector", func() (StrideLimit, error) { return p.MaxStrideVector(3, 4) },
+			func(stride float64) func(leg *Leg) IntermediateEffectorCoordinates {
+				return func(leg *Leg) IntermediateEffectorCoordinates {
+					return strideVectorPath(neutralEffector(leg), stride*0.6, stride*0.8, p.InterpolationSteps)
+				}
+			}},
+		{"rotation", func() (StrideLimit, error) { return p.MaxStrideRotation(-1) },
+			func(stride float64) func(leg *Leg) IntermediateEffectorCoordinates {
+				return func(leg *Leg) IntermediateEffectorCoordinates {
+					return rotationPath(neutralEffector(leg), stride, p.InterpolationSteps)
+				}
+			}},
+	}
+
+	for _, test := range tests {
+		limit, err := test.limit()
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if limit.Stride == 0 || limit.Leg < 0 || limit.Err == nil {
+			t.Fatalf("%s: limit %+v", test.name, limit)
+		}
+		if _, err := p.checkStride(test.path(limit.Stride), p.NextGait()); err != nil {
+			t.Errorf("%s: the maximum stride %2.2f fails: %v", test.name, limit.Stride, err)
+		}
+		beyond := limit.Stride + math.Copysign(2*MAX_STRIDE_SEARCH_TOLERANCE, limit.Stride)
+		if _, err := p.checkStride(test.path(beyond), p.NextGait()); err == nil {
+			t.Errorf("%s: a stride of %2.2f beyond the maximum %2.2f succeeds", test.name, beyond, limit.Stride)
+		}
+	}
+
+	if limit, _ := p.MaxStrideRotation(-1); limit.Stride > 0 {
+		t.Errorf("rotation %2.2f, want a negative rotation", limit.Stride)
+	}
+	if _, err := p.MaxStrideVector(0, 0); err == nil {
+		t.Error("no error for a zero stride direction")
+	}
+}
+
+func TestStrideForSpeed(t *testing.T) {
+	gait, err := NewHexapodGait(TRIPOD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stride := StrideForSpeed(gait, 100)
+	// The body moves the stride path (twice the stride vector) during the stance phase
+	if speed := 2 * stride / gait.StanceDuration(); math.Abs(speed-100) > 1e-9 {
+		t.Errorf("stride %2.2f walks at %2.2f mm/s, want 100 mm/s", stride, speed)
+	}
+}
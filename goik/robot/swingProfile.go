@@ -0,0 +1,238 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+/*
+	Notes regarding swing profiles
+
+	1) A swing profile describes the trajectory of the end effector through the swing phase. Given the progress
+	   (0-1) through the swing phase, the profile returns how far the end effector has moved along the stride
+	   path (0-1) and how high it is lifted (as a fraction of the lift height).
+	2) Profiles:
+		- Sine:        Constant speed along the path and a half sine arc. The end effector leaves and
+		               reaches the ground at an angle.
+		- Cycloid:     The path of a point on a rolling wheel. Starts and stops with zero speed in all directions.
+		- Quintic:     Minimum jerk polynomials for both the horizontal and the vertical movement.
+		- Rectangular: Lift straight up, move along the path and place straight down (minimum jerk for every part).
+		               Keeps the end effector from scuffing the ground.
+		- Bezier:      A Bezier curve from lift-off to touchdown through user defined control points.
+	3) The vertical speed at lift-off and touchdown may be given (mm/s). The profile is then adjusted so
+	   that it leaves and reaches the ground at these speeds, while the height at the start and the end is unchanged.
+*/
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type SwingProfileType int
+
+const (
+	SINE        SwingProfileType = 0
+	CYCLOID     SwingProfileType = 1
+	QUINTIC     SwingProfileType = 2
+	RECTANGULAR SwingProfileType = 3
+	BEZIER      SwingProfileType = 4
+)
+
+var swingProfileTypes = []SwingProfileType{SINE, CYCLOID, QUINTIC, RECTANGULAR, BEZIER}
+
+func (t SwingProfileType) String() string {
+	switch t {
+	case SINE:
+		return "sine"
+	case CYCLOID:
+		return "cycloid"
+	case QUINTIC:
+		return "quintic"
+	case RECTANGULAR:
+		return "rectangular"
+	case BEZIER:
+		return "bezier"
+	}
+	return fmt.Sprintf("SwingProfileType(%d)", int(t))
+}
+
+// ParseSwingProfileType returns the swing profile type with a given name (see String)
+func ParseSwingProfileType(name string) (SwingProfileType, error) {
+	for _, t := range swingProfileTypes {
+		if strings.EqualFold(name, t.String()) {
+			return t, nil
+		}
+	}
+	return SINE, fmt.Errorf("unknown swing profile '%s' (sine, cycloid, quintic, rectangular or bezier)", name)
+}
+
+// MarshalText stores the swing profile type by name in JSON files
+func (t SwingProfileType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText reads a swing profile type by name from JSON files
+func (t *SwingProfileType) UnmarshalText(text []byte) error {
+	parsed, err := ParseSwingProfileType(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// Fraction of the swing phase used for lifting and placing the end effector in the rectangular profile
+const RECTANGULAR_LIFT_FRACTION = 0.25
+
+// SwingPoint is a control point of a Bezier swing profile
+type SwingPoint struct {
+	// Fraction (0-1) of the way along the stride path
+	Progress float64
+	// Fraction of the lift height
+	Height float64
+}
+
+// Control points used by a Bezier profile without control points. The end effector
+// leaves and reaches the ground vertically, and the top of the curve is at the lift height
+var defaultBezierPoints = []SwingPoint{{0, 4.0 / 3}, {1, 4.0 / 3}}
+
+// SwingProfile describes the trajectory of the end effector in the swing phase (see the notes above)
+type SwingProfile struct {
+	Type SwingProfileType
+	// Maximum height (mm) of the end effector above the stride path (the lift height of the gait is used if 0)
+	Height float64 `json:",omitempty"`
+	// Vertical speed (mm/s) of the end effector when it leaves and reaches the ground.
+	// The speed given by the profile is used if nil
+	LiftOffSpeed   *float64 `json:",omitempty"`
+	TouchdownSpeed *float64 `json:",omitempty"`
+	// Control points of a Bezier profile (between lift-off and touchdown)
+	ControlPoints []SwingPoint `json:",omitempty"`
+}
+
+// Validate returns an error if the swing profile is unusable
+func (s *SwingProfile) Validate() error {
+	if s.Height < 0 {
+		return fmt.Errorf("the swing height can not be negative (was %2.2f)", s.Height)
+	}
+	if s.Type < SINE || s.Type > BEZIER {
+		return fmt.Errorf("unknown swing profile type %d", int(s.Type))
+	}
+	if s.Type != BEZIER && len(s.ControlPoints) > 0 {
+		return fmt.Errorf("control points can only be used with a bezier swing profile")
+	}
+	for i, c := range s.ControlPoints {
+		if c.Progress < 0 || c.Progress > 1 {
+			return fmt.Errorf("control point %d must be between the start (0) and the end (1) of the stride path (was %2.2f)", i, c.Progress)
+		}
+	}
+	return nil
+}
+
+// minimumJerk is the minimum jerk (quintic) transition from 0 to 1 for x in [0, 1]
+func minimumJerk(x float64) float64 {
+	x = math.Max(0, math.Min(1, x))
+	return x * x * x * (10 + x*(-15+6*x))
+}
+
+// bezier returns the point at t (0-1) on the Bezier curve from (0, 0) to (1, 0) through the control points
+func bezier(points []SwingPoint, t float64) (float64, float64) {
+	curve := append([]SwingPoint{{0, 0}}, points...)
+	curve = append(curve, SwingPoint{1, 0})
+	// De Casteljau's algorithm
+	for n := len(curve) - 1; n > 0; n-- {
+		for i := 0; i < n; i++ {
+			curve[i] = SwingPoint{
+				curve[i].Progress + (curve[i+1].Progress-curve[i].Progress)*t,
+				curve[i].Height + (curve[i+1].Height-curve[i].Height)*t}
+		}
+	}
+	return curve[0].Progress, curve[0].Height
+}
+
+// shape returns how far (0-1) along the stride path the end effector is, and how high it is lifted
+// (as a fraction of the lift height) at a given progress (0-1) through the swing phase
+func (s *SwingProfile) shape(t float64) (float64, float64) {
+	t = math.Max(0, math.Min(1, t))
+	switch s.Type {
+	case CYCLOID:
+		return t - math.Sin(2*math.Pi*t)/(2*math.Pi), (1 - math.Cos(2*math.Pi*t)) / 2
+	case QUINTIC:
+		return minimumJerk(t), minimumJerk(2 * math.Min(t, 1-t))
+	case RECTANGULAR:
+		a := RECTANGULAR_LIFT_FRACTION
+		return minimumJerk((t - a) / (1 - 2*a)), minimumJerk(math.Min(t, 1-t) / a)
+	case BEZIER:
+		points := s.ControlPoints
+		if len(points) == 0 {
+			points = defaultBezierPoints
+		}
+		return bezier(points, t)
+	}
+	return t, math.Sin(math.Pi * t)
+}
+
+// Advance returns how far (0-1) along the stride path the end effector is at a given progress (0-1) through the swing phase
+func (s *SwingProfile) Advance(t float64) float64 {
+	h, _ := s.shape(t)
+	return h
+}
+
+// Lift returns the height (mm) of the end effector above the stride path at a given progress (0-1)
+// through a swing phase lasting swingDuration seconds
+func (s *SwingProfile) Lift(t float64, swingDuration float64) float64 {
+	_, v := s.shape(t)
+	if s.Height == 0 || (s.LiftOffSpeed == nil && s.TouchdownSpeed == nil) {
+		return s.Height * v
+	}
+
+	// Adjust the slope (lift heights per swing phase) at lift-off and touchdown, without changing the ends of the curve
+	const dt = 1e-4
+	_, v0 := s.shape(dt)
+	_, v1 := s.shape(1 - dt)
+	t = math.Max(0, math.Min(1, t))
+	if s.LiftOffSpeed != nil {
+		v += (*s.LiftOffSpeed*swingDuration/s.Height - v0/dt) * t * (1 - t) * (1 - t)
+	}
+	if s.TouchdownSpeed != nil {
+		v += (*s.TouchdownSpeed*swingDuration/s.Height - v1/dt) * t * t * (1 - t)
+	}
+	return s.Height * v
+}
+
+// maxAdvanceRate returns the highest speed along the stride path relative to a constant speed through the swing phase
+func (s *SwingProfile) maxAdvanceRate() float64 {
+	rate := 1.0
+	const samples = 100
+	previous := 0.0
+	for i := 1; i <= samples; i++ {
+		h := s.Advance(float64(i) / samples)
+		rate = math.Max(rate, (h-previous)*samples)
+		previous = h
+	}
+	return rate
+}
+
+// String returns a short description of the swing profile
+func (s *SwingProfile) String() string {
+	description := fmt.Sprintf("%s, height %2.2f mm", s.Type, s.Height)
+	if s.LiftOffSpeed != nil {
+		description += fmt.Sprintf(", lift-off %2.2f mm/s", *s.LiftOffSpeed)
+	}
+	if s.TouchdownSpeed != nil {
+		description += fmt.Sprintf(", touchdown %2.2f mm/s", *s.TouchdownSpeed)
+	}
+	for _, c := range s.ControlPoints {
+		description += fmt.Sprintf(" (%2.2f, %2.2f)", c.Progress, c.Height)
+	}
+	return description
+}
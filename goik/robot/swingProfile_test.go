@@ -0,0 +1,117 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// Every profile lifts off at the start of the stride path and touches down at the end of it,
+// with the top of the arc at the lift height halfway through the swing phase
+func TestSwingProfileShape(t *testing.T) {
+	profiles := []SwingProfile{
+		{Type: SINE},
+		{Type: CYCLOID},
+		{Type: QUINTIC},
+		{Type: RECTANGULAR},
+		{Type: BEZIER},
+		{Type: BEZIER, ControlPoints: []SwingPoint{{0.2, 1}, {0.5, 2}, {1.2, 0.5}}},
+	}
+
+	for _, s := range profiles {
+		for _, end := range []struct{ t, advance float64 }{{0, 0}, {1, 1}} {
+			advance, lift := s.shape(end.t)
+			if math.Abs(advance-end.advance) > 1e-9 || math.Abs(lift) > 1e-9 {
+				t.Errorf("%s at %2.0f: advance %2.4f and lift %2.4f, want %2.0f and 0", s.String(), end.t, advance, lift, end.advance)
+			}
+		}
+		if len(s.ControlPoints) > 0 {
+			continue
+		}
+		if advance, lift := s.shape(0.5); math.Abs(advance-0.5) > 1e-9 || math.Abs(lift-1) > 1e-9 {
+			t.Errorf("%s halfway: advance %2.4f and lift %2.4f, want 0.5 and 1", s.String(), advance, lift)
+		}
+		if s.maxAdvanceRate() < 1 {
+			t.Errorf("%s: advance rate %2.4f", s.String(), s.maxAdvanceRate())
+		}
+	}
+}
+
+// Lift-off and touchdown speeds change the slope at the ends of the arc, but not the ends themselves
+func TestSwingProfileSpeeds(t *testing.T) {
+	liftOff, touchdown := 200.0, -50.0
+	s := SwingProfile{Type: QUINTIC, Height: 30, LiftOffSpeed: &liftOff, TouchdownSpeed: &touchdown}
+	duration := 0.5
+
+	if s.Lift(0, duration) != 0 || math.Abs(s.Lift(1, duration)) > 1e-9 {
+		t.Errorf("lift %2.4f mm at lift-off and %2.4f mm at touchdown", s.Lift(0, duration), s.Lift(1, duration))
+	}
+	const dt = 1e-6
+	speed := func(t float64) float64 {
+		return (s.Lift(t+dt, duration) - s.Lift(t, duration)) / (dt * duration)
+	}
+	if math.Abs(speed(0)-liftOff) > 0.1 {
+		t.Errorf("lift-off at %2.2f mm/s, want %2.2f mm/s", speed(0), liftOff)
+	}
+	if math.Abs(-speed(1-dt)-touchdown) > 0.1 {
+		t.Errorf("touchdown at %2.2f mm/s, want %2.2f mm/s", -speed(1-dt), touchdown)
+	}
+}
+
+func TestValidateSwingProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile SwingProfile
+		valid   bool
+	}{
+		{"sine", SwingProfile{Type: SINE, Height: 20}, true},
+		{"bezier", SwingProfile{Type: BEZIER, ControlPoints: []SwingPoint{{0, 1}, {1, 1}}}, true},
+		{"negative height", SwingProfile{Type: SINE, Height: -1}, false},
+		{"unknown type", SwingProfile{Type: SwingProfileType(9)}, false},
+		{"control points without bezier", SwingProfile{Type: CYCLOID, ControlPoints: []SwingPoint{{0.5, 1}}}, false},
+		{"control point beyond the path", SwingProfile{Type: BEZIER, ControlPoints: []SwingPoint{{1.5, 1}}}, false},
+	}
+
+	for _, test := range tests {
+		err := test.profile.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
+
+// Swing profile types are stored by name in gait files
+func TestSwingProfileTypeJSON(t *testing.T) {
+	for _, profileType := range swingProfileTypes {
+		data, err := json.Marshal(SwingProfile{Type: profileType})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s SwingProfile
+		if err := json.Unmarshal(data, &s); err != nil || s.Type != profileType {
+			t.Errorf("%s: read %s from %s (%v)", profileType, s.Type, data, err)
+		}
+	}
+
+	var s SwingProfile
+	if err := json.Unmarshal([]byte(`{"Type": "spline"}`), &s); err == nil {
+		t.Error("no error for an unknown swing profile")
+	}
+}
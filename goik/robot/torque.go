@@ -0,0 +1,245 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Gravitational acceleration (m/s^2)
+const GRAVITY = 9.81
+
+// Stall torque (Nm) for commonly used dynamixel servos
+const (
+	XL320_STALL_TORQUE = 0.39
+	AX12_STALL_TORQUE  = 1.5
+)
+
+// TorqueSample contains the static torque on every joint at a given tick
+type TorqueSample struct {
+	// Tick in the simulated gait cycles
+	Tick int
+	// Gait cycle phase (0-1) at the tick
+	Phase float64
+	// Holding torque (Nm) for each joint in each leg [leg][joint]. This is the
+	// torque the servo must deliver to keep the joint from moving
+	Torques [][]float64
+}
+
+// TorqueReport contains the static joint torques during a gait cycle
+type TorqueReport struct {
+	Samples []TorqueSample
+	// Peak (absolute) torque (Nm) for each joint in each leg [leg][joint]
+	Peak [][]float64
+	// Root mean square torque (Nm) for each joint in each leg [leg][joint]
+	RMS [][]float64
+}
+
+// footForces distributes the weight (N) of the pod between the grounded feet, so that the
+// vertical ground reaction forces balance the weight around the centre of mass.
+// With more than three feet on the ground the problem is underdetermined, and the
+// least squares (minimum norm) distribution is used.
+func footForces(feet []Coordinate, com Coordinate, weight float64) []float64 {
+	n := len(feet)
+	if n == 0 {
+		return nil
+	}
+
+	// Sum of forces == weight, and the moments around the X and Y axes must balance
+	A := mat.NewDense(3, n, nil)
+	for i, f := range feet {
+		A.Set(0, i, 1)
+		A.Set(1, i, f.X)
+		A.Set(2, i, f.Y)
+	}
+	b := mat.NewVecDense(3, []float64{weight, weight * com.X, weight * com.Y})
+
+	var forces mat.VecDense
+	err := forces.SolveVec(A, b)
+	if err != nil {
+		// The feet are on a line (or in the same spot). Share the weight equally
+		equal := make([]float64, n)
+		for i := range equal {
+			equal[i] = weight / float64(n)
+		}
+		return equal
+	}
+	return forces.RawVector().Data
+}
+
+// addTorque adds the torque (Nm) around an axis through origin from a force (N) applied at a point (mm)
+func addTorque(torque *float64, axis Coordinate, origin Coordinate, point Coordinate, force Coordinate) {
+	r := NewCoordinate((point.X-origin.X)/1000, (point.Y-origin.Y)/1000, (point.Z-origin.Z)/1000)
+	moment := NewCoordinate(r.Y*force.Z-r.Z*force.Y, r.Z*force.X-r.X*force.Z, r.X*force.Y-r.Y*force.X)
+	*torque += axis.X*moment.X + axis.Y*moment.Y + axis.Z*moment.Z
+}
+
+// jointTorques returns the holding torque (Nm) for each joint in a leg given the
+// vertical ground reaction force (N) on the end effector
+func (l *Leg) jointTorques(masses LegMasses, groundForce float64) []float64 {
+	frames := l.Frames()
+	n := len(l.Chain)
+	torques := make([]float64, n)
+
+	// Remember that positive Z points towards the ground
+	gravity := func(grams float64) Coordinate { return NewCoordinate(0, 0, grams/1000*GRAVITY) }
+
+	for j := 0; j < n; j++ {
+		axis := NewCoordinate(frames[j].At(0, 2), frames[j].At(1, 2), frames[j].At(2, 2))
+		origin := l.GetJointOrigin(frames[j])
+
+		var external float64
+		addTorque(&external, axis, origin, l.Joints[n], NewCoordinate(0, 0, -groundForce))
+
+		// Only the parts of the leg beyond the joint load the joint
+		for k := j; k < n; k++ {
+			if k < len(masses.Servos) {
+				addTorque(&external, axis, origin, l.Joints[k], gravity(masses.Servos[k]))
+			}
+			if k < len(masses.Segments) {
+				a := l.Joints[k]
+				b := l.Joints[k+1]
+				addTorque(&external, axis, origin, NewCoordinate((a.X+b.X)/2, (a.Y+b.Y)/2, (a.Z+b.Z)/2), gravity(masses.Segments[k]))
+			}
+		}
+		torques[j] = -external
+	}
+	return torques
+}
+
+// CalculateTorques returns the static holding torque (Nm) for each joint in each leg [leg][joint]
+// in the current stance. The weight of the pod is shared between the legs in the stance phase.
+func (p *Pod) CalculateTorques() ([][]float64, error) {
+	if p.BodyDefinition.Masses == nil {
+		return nil, fmt.Errorf("no mass properties defined for the pod")
+	}
+
+	var feet []Coordinate
+	var stance []int
+	for i, l := range p.Legs {
+		if p.isGrounded(i) {
+			feet = append(feet, l.Effector())
+			stance = append(stance, i)
+		}
+	}
+	forces := footForces(feet, p.CenterOfMass(), p.TotalMass()/1000*GRAVITY)
+
+	torques := make([][]float64, len(p.Legs))
+	for i, l := range p.Legs {
+		groundForce := 0.0
+		for f, s := range stance {
+			if s == i {
+				groundForce = forces[f]
+			}
+		}
+		torques[i] = l.jointTorques(p.BodyDefinition.GetLegMasses(i), groundForce)
+	}
+	return torques, nil
+}
+
+// EstimateTorques runs a copy of the pod through two full gait cycles (using the current gait,
+// stride and body pose) and calculates the static joint torques at every tick.
+// If no stride has been defined, the torques for the current stance are returned.
+func (p *Pod) EstimateTorques() (*TorqueReport, error) {
+	torques, err := p.CalculateTorques()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TorqueReport{}
+	report.Samples = append(report.Samples, TorqueSample{Tick: 0, Phase: p.CyclePhase, Torques: torques})
+
+	if p.HasDefinedStride() {
+		sim := p.simulationCopy()
+		ticks := sim.simulationTicks()
+		for tick := 1; tick <= ticks; tick++ {
+			sim.UpdateMovement()
+			torques, err := sim.CalculateTorques()
+			if err != nil {
+				return nil, err
+			}
+			report.Samples = append(report.Samples, TorqueSample{Tick: tick, Phase: sim.CyclePhase, Torques: torques})
+		}
+	}
+
+	for l := range torques {
+		report.Peak = append(report.Peak, make([]float64, len(torques[l])))
+		report.RMS = append(report.RMS, make([]float64, len(torques[l])))
+	}
+	for _, s := range report.Samples {
+		for l := range s.Torques {
+			for j, t := range s.Torques[l] {
+				report.Peak[l][j] = math.Max(report.Peak[l][j], math.Abs(t))
+				report.RMS[l][j] += t * t
+			}
+		}
+	}
+	for l := range report.RMS {
+		for j := range report.RMS[l] {
+			report.RMS[l][j] = math.Sqrt(report.RMS[l][j] / float64(len(report.Samples)))
+		}
+	}
+
+	return report, nil
+}
+
+// WriteCSV writes the torque for every joint at every tick, followed by the peak and RMS torques
+func (r *TorqueReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"tick", "phase"}
+	for l := range r.Peak {
+		for j := range r.Peak[l] {
+			header = append(header, fmt.Sprintf("leg %d %s (Nm)", l, JointName(j)))
+		}
+	}
+	writer.Write(header)
+
+	row := func(first string, second string, values [][]float64) {
+		record := []string{first, second}
+		for l := range values {
+			for _, v := range values[l] {
+				record = append(record, fmt.Sprintf("%.4f", v))
+			}
+		}
+		writer.Write(record)
+	}
+
+	for _, s := range r.Samples {
+		row(fmt.Sprintf("%d", s.Tick), fmt.Sprintf("%.4f", s.Phase), s.Torques)
+	}
+	row("peak", "", r.Peak)
+	row("rms", "", r.RMS)
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// Export saves the torque report to a CSV file
+func (r *TorqueReport) Export(path string) error {
+	fo, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fo.Close()
+
+	return r.WriteCSV(fo)
+}
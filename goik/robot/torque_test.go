@@ -0,0 +1,131 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"testing"
+)
+
+// The foot forces carry the weight, and balance it around the centre of mass
+func TestFootForces(t *testing.T) {
+	tests := []struct {
+		name string
+		feet []Coordinate
+		com  Coordinate
+	}{
+		{"tripod", []Coordinate{{X: 100, Y: 0}, {X: -50, Y: 80}, {X: -50, Y: -80}}, Coordinate{X: 10, Y: 5}},
+		{"four feet", []Coordinate{{X: 100, Y: 100}, {X: -100, Y: 100}, {X: -100, Y: -100}, {X: 100, Y: -100}}, Coordinate{X: -20}},
+		{"six feet", []Coordinate{{X: 120}, {X: 60, Y: 100}, {X: -60, Y: 100}, {X: -120}, {X: -60, Y: -100}, {X: 60, Y: -100}},
+			Coordinate{Y: 15}},
+	}
+
+	for _, test := range tests {
+		weight := 10.0
+		forces := footForces(test.feet, test.com, weight)
+		if len(forces) != len(test.feet) {
+			t.Fatalf("%s: %d forces, want %d", test.name, len(forces), len(test.feet))
+		}
+		var sum, x, y float64
+		for i, f := range forces {
+			sum += f
+			x += f * test.feet[i].X
+			y += f * test.feet[i].Y
+		}
+		if math.Abs(sum-weight) > 1e-9 {
+			t.Errorf("%s: forces %v sum to %2.4f N, want %2.4f N", test.name, forces, sum, weight)
+		}
+		if math.Abs(x-weight*test.com.X) > 1e-6 || math.Abs(y-weight*test.com.Y) > 1e-6 {
+			t.Errorf("%s: forces %v do not balance around %+v", test.name, forces, test.com)
+		}
+	}
+
+	// Feet on a line can not balance the weight, so it is shared equally
+	forces := footForces([]Coordinate{{X: -100}, {X: 0}, {X: 100}}, Coordinate{X: 20}, 9)
+	for _, f := range forces {
+		if f != 3 {
+			t.Errorf("forces %v, want 3 N on each foot", forces)
+			break
+		}
+	}
+	if footForces(nil, Coordinate{}, 1) != nil {
+		t.Error("forces without feet")
+	}
+}
+
+func TestJointTorques(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	l := p.Legs[0]
+
+	for j, torque := range l.jointTorques(LegMasses{}, 0) {
+		if torque != 0 {
+			t.Errorf("%s: torque %2.4f Nm on a massless unloaded leg", JointName(j), torque)
+		}
+	}
+
+	// The coxa rotates around a vertical axis, so the vertical ground force does not load it.
+	// The femur carries the force at the horizontal distance to the foot
+	force := 2.0
+	torques := l.jointTorques(LegMasses{}, force)
+	if math.Abs(torques[0]) > 1e-9 {
+		t.Errorf("coxa torque %2.4f Nm, want 0", torques[0])
+	}
+	femur, foot := l.Joints[1], l.Effector()
+	want := force * math.Hypot(foot.X-femur.X, foot.Y-femur.Y) / 1000
+	if math.Abs(math.Abs(torques[1])-want) > 1e-9 {
+		t.Errorf("femur torque %2.4f Nm, want %2.4f Nm", torques[1], want)
+	}
+}
+
+func TestEstimateTorques(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	if err := p.SetStrideVector(1, 20, 0); err != nil {
+		t.Fatal(err)
+	}
+	report, err := p.EstimateTorques()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Samples) != p.simulationTicks()+1 {
+		t.Errorf("%d samples, want %d", len(report.Samples), p.simulationTicks()+1)
+	}
+	for l := range report.Peak {
+		for j := range report.Peak[l] {
+			// The coxa is not loaded by the vertical forces
+			if report.RMS[l][j] > report.Peak[l][j] || j > 0 && report.Peak[l][j] == 0 {
+				t.Errorf("leg %d, %s: peak %2.4f Nm, RMS %2.4f Nm", l, JointName(j), report.Peak[l][j], report.RMS[l][j])
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	if err := report.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Header, samples, peak and RMS
+	if len(records) != len(report.Samples)+3 || len(records[0]) != 2+len(p.Legs)*3 {
+		t.Errorf("%d rows of %d columns", len(records), len(records[0]))
+	}
+
+	if _, err := NewPod(NewExampleHexapod0()).EstimateTorques(); err == nil {
+		t.Error("no error without a mass model")
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"fmt"
+	"math"
+)
+
+/*
+	Notes regarding the velocity mode
+
+	1) The caller sets the linear velocity and the yaw rate of the body (see SetVelocity) at any time.
+	   The pod keeps walking, so it can steer continuously without stopping or reverting.
+	2) Every time a leg lifts off, its stride path is planned again from the current velocity and gait.
+	   The swing phase takes the leg to where it must land for the stance phase at the new velocity.
+	3) With a constant velocity and yaw rate the body moves along a circular arc, and a grounded end
+	   effector describes an arc around the centre of that circle (in the body frame). With no yaw rate
+	   the arc is a straight line.
+	4) Legs in the stance phase finish their current stride, so a change of velocity is phased in over one
+	   gait cycle.
+*/
+
+// Velocity of the body in the base reference frame
+type Velocity struct {
+	// Linear velocity (mm/s)
+	X float64
+	Y float64
+	// Yaw rate (deg/s). Positive rates turn the body the same way as a positive SetRotation
+	Yaw float64
+}
+
+// pivot returns the point the body turns around (nil if the body does not turn)
+func (v Velocity) pivot() *Coordinate {
+	if v.Yaw == 0 {
+		return nil
+	}
+	// The point where the velocity of the body cancels the velocity from the yaw rate
+	yaw := v.Yaw * math.Pi / 180
+	return &Coordinate{X: -v.Y / yaw, Y: v.X / yaw}
+}
+
+// footSpeed returns the speed (mm/s) of an end effector at c relative to the body
+func (v Velocity) footSpeed(c Coordinate) float64 {
+	yaw := v.Yaw * math.Pi / 180
+	return math.Hypot(v.X-yaw*c.Y, v.Y+yaw*c.X)
+}
+
+// twistPath returns the end effector path (with a given number of steps) for a leg that is centered on the end effector location c
+// while the body moves by (x, y) (mm) and turns by radians during the stance phase, with constant
+// velocity and yaw rate. The body moves in the direction of (x, y) when the leg moves along the path
+// from the end to the start, the same way as for strideVectorPath
+func twistPath(c Coordinate, x float64, y float64, radians float64, steps int) IntermediateEffectorCoordinates {
+	path := make(IntermediateEffectorCoordinates, steps)
+
+	for i := 0; i < steps; i++ {
+		// Fraction (-0.5 to 0.5) of the stance phase, relative to the middle of the stance phase
+		s := 0.5 - float64(i)/float64(steps-1)
+		angle := radians * s
+
+		// Body displacement after integrating the velocity (rotating with the body) from the middle of the stance phase
+		dx, dy := s*x, s*y
+		if radians != 0 {
+			sin := math.Sin(angle) / radians
+			cos := (1 - math.Cos(angle)) / radians
+			dx = x*sin - y*cos
+			dy = x*cos + y*sin
+		}
+
+		// The end effector stays on the ground, so it moves the opposite way in the body frame
+		px := c.X - dx
+		py := c.Y - dy
+		path[i] = NewCoordinate(
+			px*math.Cos(angle)+py*math.Sin(angle),
+			-px*math.Sin(angle)+py*math.Cos(angle),
+			POD_Z_HEIGHT)
+	}
+	return path
+}
+
+// velocityPath returns the stride path (with a given number of steps) for a leg walking at a velocity
+// with a gait. The path is centered on the neutral stance
+func velocityPath(leg *Leg, v Velocity, gait *Gait, steps int) IntermediateEffectorCoordinates {
+	stance := gait.StanceDuration()
+	return twistPath(neutralEffector(leg), v.X*stance, v.Y*stance, v.Yaw*stance*math.Pi/180, steps)
+}
+
+// maxFootSpeed returns the highest speed (mm/s) of any end effector in the neutral stance
+// relative to the body. Without a yaw rate this is the speed of the body
+func (p *Pod) maxFootSpeed(v Velocity) float64 {
+	speed := 0.0
+	for _, leg := range p.Legs {
+		speed = math.Max(speed, v.footSpeed(neutralEffector(leg)))
+	}
+	return speed
+}
+
+// SetVelocity walks the pod at a velocity in the velocity mode. The velocity may be changed at any time,
+// and a walking pod keeps walking while the legs plan their strides for the new velocity (see the notes above).
+// With auto gait enabled (see SetAutoGait), the gait is selected from the fastest end effector speed.
+// The pod walks nrepeats gait cycles (0 walks until the pod is stopped)
+func (p *Pod) SetVelocity(nrepeats int, v Velocity) error {
+	err := p.checkStrideChange()
+	if err != nil {
+		return err
+	}
+	gait := p.NextGait()
+	gaitType := p.autoGaitType
+	if p.AutoGait != nil {
+		gaitType = p.AutoGait.Select(p.autoGaitType, p.maxFootSpeed(v))
+		if !p.autoGaitSelected || gaitType != p.autoGaitType {
+			var err error
+			gait, err = NewGait(len(p.Legs), gaitType)
+			if err != nil {
+				return err
+			}
+			p.timeGait(gait)
+		}
+	}
+
+	legIndex, err := p.checkStride(func(leg *Leg) IntermediateEffectorCoordinates {
+		return velocityPath(leg, v, gait, p.InterpolationSteps)
+	}, gait)
+	if err != nil {
+		return fmt.Errorf("leg %d is unable to walk at this velocity with the %s: %w", legIndex, gait.Name, err)
+	}
+
+	// A walking pod plans the strides as the legs lift off
+	if !p.IsWalking() {
+		err = p.setStridePaths(func(leg *Leg) IntermediateEffectorCoordinates {
+			return velocityPath(leg, v, gait, p.InterpolationSteps)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if gait != p.NextGait() {
+		err = p.SetGait(gait)
+		if err != nil {
+			return err
+		}
+	}
+	if p.AutoGait != nil {
+		p.autoGaitType = gaitType
+		p.autoGaitSelected = true
+	}
+
+	p.setTargetGaitCycles(nrepeats)
+	p.Velocity = &v
+	p.StridePivot = v.pivot()
+
+	if p.IsWalking() {
+		return nil
+	}
+	p.defineStride()
+	return nil
+}
+
+// replanStride plans the stride of a leg lifting off for the current velocity and gait.
+// The leg keeps its previous stride if it is unable to follow the new stride path
+func (p *Pod) replanStride(leg *Leg, gait *Gait) {
+	if p.Velocity == nil {
+		return
+	}
+	path := velocityPath(leg, *p.Velocity, gait, p.InterpolationSteps)
+	if err := p.checkStridePath(leg, path, gait); err != nil {
+		return
+	}
+	p.setStridePath(leg, path)
+}
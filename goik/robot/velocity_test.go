@@ -0,0 +1,127 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+	"testing"
+)
+
+// Without a yaw rate the twist path is the straight stride path
+func TestTwistPathStraight(t *testing.T) {
+	c := NewCoordinate(120, 40, POD_Z_HEIGHT)
+	twist := twistPath(c, 30, -20, 0, 9)
+	stride := strideVectorPath(c, 15, -10, 9)
+	for i := range twist {
+		if !closeTo(twist[i], stride[i], 1e-9) {
+			t.Fatalf("twist path %v, want %v", twist, stride)
+		}
+	}
+}
+
+// With a yaw rate a grounded end effector moves along an arc around the pivot of the body
+func TestTwistPathArc(t *testing.T) {
+	v := Velocity{X: 40, Y: 10, Yaw: 20}
+	stance := 0.5
+	x, y, radians := v.X*stance, v.Y*stance, v.Yaw*stance*math.Pi/180
+	// The point where the velocity of the body cancels the velocity from the yaw rate
+	pivot := Coordinate{X: -y / radians, Y: x / radians}
+	if v.footSpeed(pivot) > 1e-9 {
+		t.Fatalf("an end effector at the pivot %+v moves at %2.2f mm/s", pivot, v.footSpeed(pivot))
+	}
+
+	c := NewCoordinate(120, 40, POD_Z_HEIGHT)
+	path := twistPath(c, x, y, radians, 11)
+	if !closeTo(path[5], c, 1e-9) {
+		t.Errorf("the path is centered on %+v, want %+v", path[5], c)
+	}
+	radius := math.Hypot(c.X-pivot.X, c.Y-pivot.Y)
+	for i, p := range path {
+		if math.Abs(math.Hypot(p.X-pivot.X, p.Y-pivot.Y)-radius) > 1e-9 {
+			t.Errorf("step %d at %+v leaves the arc around %+v", i, p, pivot)
+		}
+	}
+	// The end effector sweeps the angle the body turns
+	a := math.Atan2(path[0].Y-pivot.Y, path[0].X-pivot.X)
+	b := math.Atan2(path[10].Y-pivot.Y, path[10].X-pivot.X)
+	if math.Abs(math.Abs(b-a)-radians) > 1e-9 {
+		t.Errorf("the path sweeps %2.4f radians, want %2.4f", math.Abs(b-a), radians)
+	}
+}
+
+func TestSetVelocity(t *testing.T) {
+	p := NewPod(NewExampleHexapod1())
+	if err := p.SetAutoGait(NewAutoGait()); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetVelocity(0, Velocity{X: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if p.NextGait().Name != "Wave gait" {
+		t.Errorf("walking slowly with the %s", p.NextGait().Name)
+	}
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		p.Clock.Tick()
+		p.Update()
+	}
+
+	// The pod keeps walking while the velocity changes
+	if err := p.SetVelocity(0, Velocity{X: 80, Yaw: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsWalking() || p.NextGait().Name != "Tripod gait" {
+		t.Errorf("state %s with the %s, want walking with the tripod gait", p.State(), p.NextGait().Name)
+	}
+
+	if err := p.SetVelocity(0, Velocity{X: 10000}); err == nil {
+		t.Error("no error walking at 10 m/s")
+	}
+}
+
+// The body turns around the point where the velocity of the body cancels the velocity from the yaw rate
+func TestVelocityPivot(t *testing.T) {
+	tests := []struct {
+		v     Velocity
+		pivot *Coordinate
+	}{
+		{Velocity{X: 40}, nil},
+		{Velocity{Yaw: 10}, &Coordinate{}},
+		{Velocity{X: 10 * math.Pi / 180, Yaw: 10}, &Coordinate{Y: 1}},
+		{Velocity{X: 40, Y: -20, Yaw: -15}, nil},
+	}
+
+	for _, test := range tests {
+		pivot := test.v.pivot()
+		if test.v.Yaw == 0 {
+			if pivot != nil {
+				t.Errorf("%+v: pivot %+v without a yaw rate", test.v, *pivot)
+			}
+			continue
+		}
+		if pivot == nil {
+			t.Errorf("%+v: no pivot", test.v)
+			continue
+		}
+		if test.pivot != nil && !closeTo(*pivot, *test.pivot, 1e-9) {
+			t.Errorf("%+v: pivot %+v, want %+v", test.v, *pivot, *test.pivot)
+		}
+		if speed := test.v.footSpeed(*pivot); speed > 1e-9 {
+			t.Errorf("%+v: the pivot moves at %2.2f mm/s", test.v, speed)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"fmt"
+	"math"
+)
+
+// Parameters for the workspace sampler
+const (
+	// Upper bound for the number of joint configurations sampled when sweeping all joints
+	WORKSPACE_MAX_SAMPLES = 100000
+	// Upper bound for the number of joint configurations sampled for the vertical section
+	// (the coxa is kept at its current angle)
+	WORKSPACE_MAX_SECTION_SAMPLES = 20000
+	// Joints without defined limits are swept through +-WORKSPACE_UNLIMITED_RANGE degrees
+	WORKSPACE_UNLIMITED_RANGE = 180.0
+)
+
+// Workspace describes the region a leg's end effector is able to reach.
+// All coordinates are in the base reference frame, and the workspace is sampled
+// for the body pose the leg had when it was created.
+type Workspace struct {
+	// Index of the leg
+	Leg int
+	// The ground height (Z) used for calculating the ground slice
+	GroundHeight float64
+	// Number of joint configurations sampled
+	Samples int
+	// End effector locations for all sampled joint configurations (the reachable volume)
+	Points []Coordinate
+	// End effector locations with the coxa at its current angle
+	// (a vertical section through the reachable volume in the plane of the leg)
+	Section []Coordinate
+	// Locations at the ground height the end effector is able to reach (the ground slice)
+	GroundSlice []Coordinate
+	// Bounding box of the reachable volume
+	Min Coordinate
+	Max Coordinate
+	// Minimum and maximum horizontal distance from the coxa origin to the ground slice
+	MinReach float64
+	MaxReach float64
+	// Swept range (degrees) of each joint, ordered along the kinematic chain
+	JointRanges [][2]float64
+}
+
+// jointRange returns the range of motion used when sweeping a joint
+func jointRange(limit JointLimit) [2]float64 {
+	if !limit.IsDefined() {
+		return [2]float64{-WORKSPACE_UNLIMITED_RANGE, WORKSPACE_UNLIMITED_RANGE}
+	}
+	return [2]float64{limit.Min, limit.Max}
+}
+
+// sweepJoints visits every combination of joint angles on a grid with the given number of steps
+// per joint. The last joint varies fastest, and first is true when it is at the start of its range.
+func sweepJoints(ranges [][2]float64, steps []int, visit func(angles []float64, first bool)) {
+	angles := make([]float64, len(ranges))
+	var sweep func(j int)
+	sweep = func(j int) {
+		for s := 0; s < steps[j]; s++ {
+			angles[j] = ranges[j][0]
+			if steps[j] > 1 {
+				angles[j] += float64(s) * (ranges[j][1] - ranges[j][0]) / float64(steps[j]-1)
+			}
+			if j == len(ranges)-1 {
+				visit(angles, s == 0)
+			} else {
+				sweep(j + 1)
+			}
+		}
+	}
+	sweep(0)
+}
+
+// stepsPerJoint divides a sample budget evenly between a number of joints
+func stepsPerJoint(budget int, joints int) int {
+	return max(2, int(math.Pow(float64(budget), 1.0/float64(joints))))
+}
+
+// SampleWorkspace sweeps the leg through the range of motion of all joints and records the reachable
+// end effector locations. Joint configurations where the end effector crosses the ground height
+// are used for building the ground slice. The leg itself is not modified.
+func (l *Leg) SampleWorkspace(groundHeight float64) *Workspace {
+	w := &Workspace{
+		Leg:          l.Index,
+		GroundHeight: groundHeight,
+		Min:          NewCoordinate(math.Inf(1), math.Inf(1), math.Inf(1)),
+		Max:          NewCoordinate(math.Inf(-1), math.Inf(-1), math.Inf(-1)),
+		MinReach:     math.Inf(1),
+	}
+
+	n := l.NumServos()
+	for j := 0; j < n; j++ {
+		w.JointRanges = append(w.JointRanges, jointRange(l.JointLimits.Get(j)))
+	}
+
+	// The sampler is a copy of the leg, so that the forward kinematics can be
+	// recalculated without touching the state of the leg
+	sampler := &Leg{
+		Index:                      l.Index,
+		OffsetTransformationMatrix: l.OffsetTransformationMatrix,
+		BodyPose:                   l.BodyPose,
+		SegmentLengths:             l.SegmentLengths,
+		Chain:                      l.Chain,
+	}
+	sampler.RecalculateForwardKinematics(ServoAngles{})
+	coxaOrigin := sampler.Joints[COXA_ORIGIN_INDEX]
+
+	steps := make([]int, n)
+	for j := range steps {
+		steps[j] = stepsPerJoint(WORKSPACE_MAX_SAMPLES, n)
+	}
+
+	var previous Coordinate
+	sweepJoints(w.JointRanges, steps, func(angles []float64, first bool) {
+		sampler.RecalculateForwardKinematics(NewServoAnglesFromValues(angles))
+		e := sampler.Effector()
+		w.Samples++
+		w.Points = append(w.Points, e)
+
+		w.Min = NewCoordinate(math.Min(w.Min.X, e.X), math.Min(w.Min.Y, e.Y), math.Min(w.Min.Z, e.Z))
+		w.Max = NewCoordinate(math.Max(w.Max.X, e.X), math.Max(w.Max.Y, e.Y), math.Max(w.Max.Z, e.Z))
+
+		// The end effector has crossed the ground height between the previous and the
+		// current sample. Interpolate to find the crossing point
+		if !first && (previous.Z-groundHeight)*(e.Z-groundHeight) <= 0 && previous.Z != e.Z {
+			t := (groundHeight - previous.Z) / (e.Z - previous.Z)
+			c := NewCoordinate(previous.X+(e.X-previous.X)*t, previous.Y+(e.Y-previous.Y)*t, groundHeight)
+			w.GroundSlice = append(w.GroundSlice, c)
+
+			reach := math.Hypot(c.X-coxaOrigin.X, c.Y-coxaOrigin.Y)
+			w.MinReach = math.Min(w.MinReach, reach)
+			w.MaxReach = math.Max(w.MaxReach, reach)
+		}
+		previous = e
+	})
+
+	// The vertical section keeps the coxa at its current angle and sweeps the remaining joints
+	if n > 1 {
+		ranges := append([][2]float64{{l.ServoAngles.Coxa, l.ServoAngles.Coxa}}, w.JointRanges[1:]...)
+		steps := make([]int, n)
+		steps[0] = 1
+		for j := 1; j < n; j++ {
+			steps[j] = stepsPerJoint(WORKSPACE_MAX_SECTION_SAMPLES, n-1)
+		}
+		sweepJoints(ranges, steps, func(angles []float64, first bool) {
+			sampler.RecalculateForwardKinematics(NewServoAnglesFromValues(angles))
+			w.Section = append(w.Section, sampler.Effector())
+		})
+	}
+
+	if len(w.GroundSlice) == 0 {
+		w.MinReach = 0
+	}
+
+	return w
+}
+
+// SampleWorkspace samples the workspace of a leg at the current ground height
+// (the end effector height of the pod in its neutral stance)
+func (p *Pod) SampleWorkspace(legNum int) (*Workspace, error) {
+	if legNum < 0 || legNum >= len(p.Legs) {
+		return nil, fmt.Errorf("invalid leg index. (Pod has %d legs. Indexing is 0 based)", len(p.Legs))
+	}
+	return p.Legs[legNum].SampleWorkspace(POD_Z_HEIGHT), nil
+}
+
+// ShowWorkspace samples the workspace of a leg and keeps it for display in the simulator views.
+// A negative leg index removes the workspace from the views
+func (p *Pod) ShowWorkspace(legNum int) error {
+	if legNum < 0 {
+		p.Workspace = nil
+		return nil
+	}
+	w, err := p.SampleWorkspace(legNum)
+	if err != nil {
+		p.Workspace = nil
+		return err
+	}
+	p.Workspace = w
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robot
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSweepJoints(t *testing.T) {
+	var visited [][]float64
+	var first []bool
+	sweepJoints([][2]float64{{0, 10}, {-90, 90}}, []int{2, 3}, func(angles []float64, f bool) {
+		visited = append(visited, append([]float64{}, angles...))
+		first = append(first, f)
+	})
+
+	want := [][]float64{{0, -90}, {0, 0}, {0, 90}, {10, -90}, {10, 0}, {10, 90}}
+	if !reflect.DeepEqual(visited, want) {
+		t.Errorf("visited %v, want %v", visited, want)
+	}
+	if !reflect.DeepEqual(first, []bool{true, false, false, true, false, false}) {
+		t.Errorf("first %v", first)
+	}
+
+	for joints := 1; joints <= 5; joints++ {
+		steps := stepsPerJoint(WORKSPACE_MAX_SAMPLES, joints)
+		if steps < 2 || math.Pow(float64(steps), float64(joints)) > WORKSPACE_MAX_SAMPLES {
+			t.Errorf("%d joints: %d steps per joint", joints, steps)
+		}
+	}
+}
+
+// The neutral stance of every leg is within its workspace
+func TestSampleWorkspace(t *testing.T) {
+	for _, body := range []func() *BodyDefinition{NewExampleHexapod1, NewTarsusHexapod, NewTwoJointHexapod} {
+		p := NewPod(body())
+		// Sampling is slow, and the legs of the example pods only differ by their placement
+		for _, l := range []*Leg{p.Legs[0], p.Legs[len(p.Legs)-1]} {
+			effector := l.Effector()
+			w, err := p.SampleWorkspace(l.Index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if l.Effector() != effector {
+				t.Errorf("leg %d moved from %+v to %+v", l.Index, effector, l.Effector())
+			}
+			if w.Samples == 0 || w.Samples > WORKSPACE_MAX_SAMPLES || len(w.Points) != w.Samples {
+				t.Errorf("leg %d: %d samples and %d points", l.Index, w.Samples, len(w.Points))
+			}
+			if len(w.JointRanges) != l.NumServos() {
+				t.Errorf("leg %d: %d joint ranges, want %d", l.Index, len(w.JointRanges), l.NumServos())
+			}
+			if len(w.GroundSlice) == 0 {
+				t.Errorf("leg %d: empty ground slice", l.Index)
+				continue
+			}
+
+			neutral := l.NeutralEffectorCoordinate
+			if neutral.X < w.Min.X || neutral.Y < w.Min.Y || neutral.Z < w.Min.Z ||
+				neutral.X > w.Max.X || neutral.Y > w.Max.Y || neutral.Z > w.Max.Z {
+				t.Errorf("leg %d: neutral position %+v outside %+v - %+v", l.Index, neutral, w.Min, w.Max)
+			}
+			coxa := l.Joints[COXA_ORIGIN_INDEX]
+			reach := math.Hypot(neutral.X-coxa.X, neutral.Y-coxa.Y)
+			// The reach is sampled, so allow for the grid spacing
+			if reach < w.MinReach-1 || reach > w.MaxReach+1 {
+				t.Errorf("leg %d: neutral reach %2.2f outside %2.2f - %2.2f", l.Index, reach, w.MinReach, w.MaxReach)
+			}
+			for _, c := range w.GroundSlice {
+				if c.Z != POD_Z_HEIGHT {
+					t.Errorf("leg %d: ground slice at Z %2.2f", l.Index, c.Z)
+					break
+				}
+			}
+		}
+	}
+
+	p := NewPod(NewExampleHexapod1())
+	if _, err := p.SampleWorkspace(len(p.Legs)); err == nil {
+		t.Error("no error for an invalid leg")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Hans Jørgen Grimstad
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"GOIK/engine"
+	"log"
+	"strings"
+
+	"github.com/borud/chatui"
+)
+
+// runShell runs the interactive command console of the simulator. The commands are sent to the
+// simulation loop (see Engine.Send), so the console never touches the pod directly
+func runShell(e *engine.Engine) {
+	s := e.Shell
+	commandCh := make(chan string)
+
+	chatui := chatui.New(chatui.Config{
+		OutputCh:     s.Output(),
+		CommandCh:    commandCh,
+		DynamicColor: false,
+		BlockCtrlC:   true,
+		HistorySize:  10,
+	})
+
+	s.Output() <- "Pod playground"
+
+	go func() {
+		for command := range commandCh {
+			if strings.ToLower(command) == "/quit" {
+				chatui.Stop()
+			}
+			err := e.Send(command)
+			if err != nil {
+				s.Output() <- err.Error()
+			}
+			chatui.SetStatus("last command was: " + command)
+		}
+	}()
+
+	go func() {
+		// this is done in a goroutine because it will block if the UI is not running.
+		chatui.SetStatus("type /quit to exit")
+
+		s.Output() <- "-----------------------------------"
+		s.Output() <- "Hexapod Designer"
+		s.Output() <- "Copyright Hans Jørgen Grimstad 2024"
+		s.Output() <- "www.TimeExpander.com"
+		s.Output() <- "-----------------------------------"
+		s.Output() <- ""
+		s.Output() <- "Type 'help' for a list of commands"
+
+	}()
+
+	err := chatui.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -15,25 +15,26 @@
 package simulator
 
 import (
-	"GOIK/comms"
+	"GOIK/engine"
 	"GOIK/robot"
 	"GOIK/views"
 	"log"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 const window_size = 1024
 
-var networkcontroller comms.NetworkController
-
 type Game struct {
-	views views.RenderViews
-	Shell *Shell
+	views  views.RenderViews
+	Engine *engine.Engine
+	// Time of the previous call to Update
+	lastUpdate time.Time
 }
 
-func NewGame(s *Shell) *Game {
-	g := Game{Shell: s}
+func NewGame(e *engine.Engine) *Game {
+	g := Game{Engine: e}
 
 	g.views = append(g.views, views.NewXzView(0, 0, window_size/2))
 	g.views = append(g.views, views.NewXyView(window_size/2, 0, window_size/2))
@@ -43,24 +44,24 @@ func NewGame(s *Shell) *Game {
 	return &g
 }
 
-var DELAY_COUNTER int = 10
-var counter int = 0
-
+// Update applies the commands from the console and advances the engine by the time since the previous frame.
+// The pod moves and the servo angles are streamed once per tick of the pod clock, so the tick rate does not
+// depend on the frame rate. Draw runs on the same goroutine, so it never sees a half updated pod
 func (g *Game) Update() error {
+	g.Engine.ApplyCommands()
 
-	counter++
-	if counter >= 3*DELAY_COUNTER {
-		g.Shell.Pod.Update()
-		networkcontroller.Update()
-		counter = 0
+	now := time.Now()
+	if !g.lastUpdate.IsZero() {
+		g.Engine.Advance(now.Sub(g.lastUpdate))
 	}
+	g.lastUpdate = now
 
 	return nil
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
 	for _, v := range g.views {
-		v.Render(screen, g.Shell.Pod)
+		v.Render(screen, g.Engine.Pod())
 	}
 }
 
@@ -73,13 +74,10 @@ func Run() {
 	// Create the pod body and define a default gait
 	pod := robot.NewPod(robot.NewExampleHexapod2())
 
-	// Create the command shell
-	shell := NewShell(pod)
-	go shell.Run()
-	g := NewGame(shell)
-
-	// Create a robot network controller
-	networkcontroller = *comms.NewNetworkController(1, pod, shell.outputCh)
+	// Create the simulation engine (with a robot network controller) and the command shell
+	e := engine.NewEngine(pod, make(chan string, 10))
+	go runShell(e)
+	g := NewGame(e)
 
 	// Create main window and start the simulation
 	ebiten.SetWindowSize(window_size, window_size)
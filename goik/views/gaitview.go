@@ -17,7 +17,6 @@ package views
 import (
 	"GOIK/robot"
 	"fmt"
-	"image/color"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
@@ -60,31 +59,46 @@ func (v *GaitView) RenderGait(screen *ebiten.Image, legend string, x_legend floa
 
 	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Cycle: %d", p.GetCurrentGaitCycle()), int(v.x+v.legendOffset), int(y-8*v.legendOffset))
 	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Samples: %d", p.GetTick()), int(v.x+v.legendOffset), int(y-6*v.legendOffset))
-	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Sample size in bytes: %d", p.GetTick()*p.BodyDefinition.NumLegs*(robot.NUM_JOINTS-1)*2), int(v.x+v.legendOffset), int(y-4*v.legendOffset))
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Sample size in bytes: %d", p.GetTick()*p.NumServos()*2), int(v.x+v.legendOffset), int(y-4*v.legendOffset))
+
+	// Hildebrand style gait diagram. Each leg has a bar covering a full gait cycle, and the
+	// swing phase starts at the leg's phase offset
+	gait := p.BodyDefinition.Gait
+	cycleWidth := 6 * width
+	swingWidth := float32(1-gait.DutyFactor) * cycleWidth
 
 	for leg := 0; leg < p.BodyDefinition.NumLegs; leg++ {
 		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Leg: %d", leg), int(x_legend), int(y+float32(leg)*height))
-		for step := 0; step < p.BodyDefinition.Gait.NumIndicesInPattern; step++ {
-			var color color.Color
-			if (*p.BodyDefinition.Gait.Pattern)[leg][step] == 1 { // Swing
-				color = SwingPassiveClr()
-				if step == p.CurrentGaitIndex {
-					color = SwingActiveClr()
-				}
-			} else { // Stance
-				color = StancePassiveClr()
-				if step == p.CurrentGaitIndex {
-					color = StanceActiveClr()
-				}
-			}
-			vector.DrawFilledRect(screen,
-				x_table+float32(step)*width,
-				y+float32(leg)*height,
-				width,
-				height, color, false)
 
+		swingClr := SwingPassiveClr()
+		stanceClr := StanceActiveClr()
+		if p.IsSwingPhase(leg) {
+			swingClr = SwingActiveClr()
+			stanceClr = StancePassiveClr()
+		}
+
+		vector.DrawFilledRect(screen, x_table, y+float32(leg)*height, cycleWidth, height, stanceClr, false)
+
+		// The swing phase may wrap around the end of the cycle
+		start := float32(gait.PhaseOffsets[leg]) * cycleWidth
+		end := start + swingWidth
+		vector.DrawFilledRect(screen, x_table+start, y+float32(leg)*height, min(end, cycleWidth)-start, height, swingClr, false)
+		if end > cycleWidth {
+			vector.DrawFilledRect(screen, x_table, y+float32(leg)*height, end-cycleWidth, height, swingClr, false)
 		}
 	}
+
+	// Current phase of the gait cycle
+	cursor := x_table + float32(p.CyclePhase)*cycleWidth
+	vector.StrokeLine(screen, cursor, y, cursor, y+float32(p.BodyDefinition.NumLegs)*height, 2, PhaseCursorClr(), false)
+	status := fmt.Sprintf("Duty factor: %.2f  Phase: %.2f", gait.DutyFactor, p.CyclePhase)
+	if p.AutoGait != nil {
+		status += "  Auto gait"
+	}
+	if p.IsChangingGait() {
+		status += fmt.Sprintf("  Changing to: %s", p.NextGait().Name)
+	}
+	ebitenutil.DebugPrintAt(screen, status, int(x_table), int(y+float32(p.BodyDefinition.NumLegs)*height+v.legendOffset))
 }
 
 func (v *GaitView) Render(screen *ebiten.Image, p *robot.Pod) {
@@ -94,7 +108,7 @@ func (v *GaitView) Render(screen *ebiten.Image, p *robot.Pod) {
 
 	y_offset += v.size / 5
 	v.RenderGait(screen,
-		fmt.Sprintf("%s - Phase: white == swing, grey == stance", p.BodyDefinition.Gait.Name),
+		fmt.Sprintf("%s - white == swing, grey == stance", p.BodyDefinition.Gait.Name),
 		x_offset,
 		pattern_offset+x_offset,
 		y_offset, p)
@@ -82,10 +82,11 @@ func (v *IsoView) Render(screen *ebiten.Image, p *robot.Pod) {
 		0, 0, 0, 1,
 	})
 
-	var IsoJoints = make([][robot.NUM_JOINTS]robot.Coordinate, p.BodyDefinition.NumLegs*robot.NUM_JOINTS)
+	var IsoJoints = make([][]robot.Coordinate, p.BodyDefinition.NumLegs)
 
 	for l := 0; l < p.BodyDefinition.NumLegs; l++ {
-		for j := 0; j < robot.NUM_JOINTS; j++ {
+		IsoJoints[l] = make([]robot.Coordinate, len(p.Legs[l].Joints))
+		for j := 0; j < len(p.Legs[l].Joints); j++ {
 			J := mat.NewDense(4, 1, []float64{
 				p.Legs[l].Joints[j].X,
 				p.Legs[l].Joints[j].Y,
@@ -121,9 +122,9 @@ func (v *IsoView) Render(screen *ebiten.Image, p *robot.Pod) {
 		White(),
 		true)
 
-	// Draw Coxa, Femur and Tibia
-	for j := 0; j < robot.NUM_JOINTS-1; j++ {
-		for l := 0; l < p.BodyDefinition.NumLegs; l++ {
+	// Draw all leg segments (Coxa, Femur, Tibia, ...)
+	for l := 0; l < p.BodyDefinition.NumLegs; l++ {
+		for j := 0; j < len(p.Legs[l].Joints)-1; j++ {
 			col := White()
 			width := 3
 			if p.IsSwingPhase(l) {
@@ -141,7 +142,7 @@ func (v *IsoView) Render(screen *ebiten.Image, p *robot.Pod) {
 	}
 
 	for l := 0; l < p.BodyDefinition.NumLegs; l++ {
-		for j := 0; j < robot.NUM_JOINTS; j++ {
+		for j := 0; j < len(IsoJoints[l]); j++ {
 			vector.DrawFilledCircle(screen, float32(v.TranslateX(IsoJoints[l][j].X)), float32(v.TranslateY(IsoJoints[l][j].Z)), 5, Red(), true)
 		}
 	}
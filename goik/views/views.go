@@ -51,6 +51,30 @@ func StanceActiveClr() color.Color {
 	return color.RGBA{64, 64, 64, 1}
 }
 
+func PhaseCursorClr() color.Color {
+	return color.RGBA{255, 0, 0, 1}
+}
+
+func WorkspaceClr() color.Color {
+	return color.RGBA{0, 96, 0, 1}
+}
+
+func GroundSliceClr() color.Color {
+	return color.RGBA{0, 192, 0, 1}
+}
+
+func PivotClr() color.Color {
+	return color.RGBA{255, 0, 255, 1}
+}
+
+func StableClr() color.Color {
+	return color.RGBA{0, 192, 0, 1}
+}
+
+func UnstableClr() color.Color {
+	return color.RGBA{255, 128, 0, 1}
+}
+
 type View interface {
 	Render(screen *ebiten.Image, p *robot.Pod)
 }
@@ -49,6 +49,13 @@ func (v *XyView) Render(screen *ebiten.Image, p *robot.Pod) {
 
 	DrawFrame(screen, "XY View ", v.size, v.x, v.y, v.legendOffset)
 
+	// Draw the sampled leg workspace
+	if p.Workspace != nil {
+		for _, c := range p.Workspace.GroundSlice {
+			vector.DrawFilledRect(screen, float32(v.TranslateX(c.X)), float32(v.TranslateY(c.Y)), 1, 1, GroundSliceClr(), false)
+		}
+	}
+
 	// Draw body frame
 	for l := 0; l < p.BodyDefinition.NumLegs-1; l++ {
 		vector.StrokeLine(screen,
@@ -69,9 +76,9 @@ func (v *XyView) Render(screen *ebiten.Image, p *robot.Pod) {
 		White(),
 		true)
 
-	// Draw Coxa, Femur and Tibia
-	for j := 0; j < robot.NUM_JOINTS-1; j++ {
-		for l := 0; l < p.BodyDefinition.NumLegs; l++ {
+	// Draw all leg segments (Coxa, Femur, Tibia, ...)
+	for l := 0; l < p.BodyDefinition.NumLegs; l++ {
+		for j := 0; j < len(p.Legs[l].Joints)-1; j++ {
 			col := White()
 			width := 3
 			if p.IsSwingPhase(l) {
@@ -97,7 +104,7 @@ func (v *XyView) Render(screen *ebiten.Image, p *robot.Pod) {
 
 	// Annotate legs with lex indexes
 	for l := 0; l < p.BodyDefinition.NumLegs; l++ {
-		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%d", l), v.TranslateX(p.Legs[l].Joints[3].X+15), v.TranslateY(p.Legs[l].Joints[3].Y+5))
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%d", l), v.TranslateX(p.Legs[l].Effector().X+15), v.TranslateY(p.Legs[l].Effector().Y+5))
 	}
 
 	// Draw IK effector targets
@@ -115,12 +122,42 @@ func (v *XyView) Render(screen *ebiten.Image, p *robot.Pod) {
 		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%d", i), v.TranslateX(t.X)+10, v.TranslateY(t.Y))
 	}
 
-	if p.HasDefinedStride {
+	// Draw the support polygon and the centre of mass
+	stability := p.Stability
+	stabilityClr := StableClr()
+	if !stability.IsStable() {
+		stabilityClr = UnstableClr()
+	}
+	polygon := stability.SupportPolygon
+	for i := range polygon {
+		a := polygon[i]
+		b := polygon[(i+1)%len(polygon)]
+		vector.StrokeLine(screen, float32(v.TranslateX(a.X)), float32(v.TranslateY(a.Y)), float32(v.TranslateX(b.X)), float32(v.TranslateY(b.Y)), 1, stabilityClr, true)
+	}
+	com := stability.CenterOfMass
+	vector.StrokeLine(screen, float32(v.TranslateX(com.X-5)), float32(v.TranslateY(com.Y)), float32(v.TranslateX(com.X+5)), float32(v.TranslateY(com.Y)), 1, stabilityClr, true)
+	vector.StrokeLine(screen, float32(v.TranslateX(com.X)), float32(v.TranslateY(com.Y-5)), float32(v.TranslateX(com.X)), float32(v.TranslateY(com.Y+5)), 1, stabilityClr, true)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Stability margin: %2.2f", stability.Margin), int(v.x+v.legendOffset), int(v.y+v.size-3*v.legendOffset))
+
+	if p.HasDefinedStride() {
+		// Draw the foot paths (straight lines or arcs)
 		for l := range p.Legs {
-			for i := range p.Legs[l].IntermediateEffectorCoordinates {
-				c := p.Legs[l].IntermediateEffectorCoordinates[i]
+			path := p.Legs[l].IntermediateEffectorCoordinates
+			for i := range path {
+				c := path[i]
 				vector.DrawFilledCircle(screen, float32(v.TranslateX(c.X)), float32(v.TranslateY(c.Y)), float32(1), White(), false)
+				if i > 0 {
+					vector.StrokeLine(screen, float32(v.TranslateX(path[i-1].X)), float32(v.TranslateY(path[i-1].Y)), float32(v.TranslateX(c.X)), float32(v.TranslateY(c.Y)), 1, White(), true)
+				}
 			}
 		}
+
+		// Draw the point the body turns around
+		if pivot := p.StridePivot; pivot != nil {
+			vector.StrokeCircle(screen, float32(v.TranslateX(pivot.X)), float32(v.TranslateY(pivot.Y)), 6, 1, PivotClr(), true)
+			vector.StrokeLine(screen, float32(v.TranslateX(pivot.X-10)), float32(v.TranslateY(pivot.Y)), float32(v.TranslateX(pivot.X+10)), float32(v.TranslateY(pivot.Y)), 1, PivotClr(), true)
+			vector.StrokeLine(screen, float32(v.TranslateX(pivot.X)), float32(v.TranslateY(pivot.Y-10)), float32(v.TranslateX(pivot.X)), float32(v.TranslateY(pivot.Y+10)), 1, PivotClr(), true)
+			ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Pivot: %2.0f, %2.0f", pivot.X, pivot.Y), int(v.x+v.legendOffset), int(v.y+v.size-5*v.legendOffset))
+		}
 	}
 }
@@ -43,6 +43,16 @@ func (v *XzView) TranslateY(y float64) int {
 func (v *XzView) Render(screen *ebiten.Image, p *robot.Pod) {
 	DrawFrame(screen, "XZ View", v.size, v.x, v.y, v.legendOffset)
 
+	// Draw the sampled leg workspace
+	if p.Workspace != nil {
+		for _, c := range p.Workspace.Section {
+			vector.DrawFilledRect(screen, float32(v.TranslateX(c.X)), float32(v.TranslateY(c.Z)), 1, 1, WorkspaceClr(), false)
+		}
+		for _, c := range p.Workspace.GroundSlice {
+			vector.DrawFilledRect(screen, float32(v.TranslateX(c.X)), float32(v.TranslateY(c.Z)), 1, 1, GroundSliceClr(), false)
+		}
+	}
+
 	// Draw body frame
 	for l := 0; l < p.BodyDefinition.NumLegs-1; l++ {
 		vector.StrokeLine(screen,
@@ -63,9 +73,9 @@ func (v *XzView) Render(screen *ebiten.Image, p *robot.Pod) {
 		White(),
 		true)
 
-	// Draw Coxa, Femur and Tibia
-	for j := 0; j < robot.NUM_JOINTS-1; j++ {
-		for l := 0; l < p.BodyDefinition.NumLegs; l++ {
+	// Draw all leg segments (Coxa, Femur, Tibia, ...)
+	for l := 0; l < p.BodyDefinition.NumLegs; l++ {
+		for j := 0; j < len(p.Legs[l].Joints)-1; j++ {
 			col := White()
 			width := 3
 			if p.IsSwingPhase(l) {